@@ -0,0 +1,66 @@
+package maxigobot
+
+import (
+	"encoding/json"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+func (c *nativeContext) Attachments() []maxigo.Attachment {
+	c.attOnce.Do(func() {
+		if msg := c.Message(); msg != nil {
+			c.attachments = parseAttachments(msg.Body.Attachments)
+		}
+	})
+	return c.attachments
+}
+
+func (c *nativeContext) Photos() []*maxigo.PhotoAttachment {
+	return attachmentsOf[*maxigo.PhotoAttachment](c.Attachments())
+}
+
+func (c *nativeContext) Files() []*maxigo.FileAttachment {
+	return attachmentsOf[*maxigo.FileAttachment](c.Attachments())
+}
+
+func (c *nativeContext) Location() *maxigo.LocationAttachment {
+	if locs := attachmentsOf[*maxigo.LocationAttachment](c.Attachments()); len(locs) > 0 {
+		return locs[0]
+	}
+	return nil
+}
+
+func (c *nativeContext) Contact() (*maxigo.ContactAttachment, bool) {
+	contacts := attachmentsOf[*maxigo.ContactAttachment](c.Attachments())
+	if len(contacts) == 0 {
+		return nil, false
+	}
+	contact := contacts[0]
+	return contact, contact.Payload.VerifyHash(c.bot.token)
+}
+
+// parseAttachments decodes raw attachments one by one, so that a single
+// malformed attachment does not hide the others.
+func parseAttachments(raw []json.RawMessage) []maxigo.Attachment {
+	var atts []maxigo.Attachment
+	for i := range raw {
+		body := maxigo.MessageBody{Attachments: raw[i : i+1]}
+		parsed, err := body.ParseAttachments()
+		if err != nil {
+			continue
+		}
+		atts = append(atts, parsed...)
+	}
+	return atts
+}
+
+// attachmentsOf returns the attachments of type T.
+func attachmentsOf[T maxigo.Attachment](atts []maxigo.Attachment) []T {
+	var out []T
+	for _, a := range atts {
+		if t, ok := a.(T); ok {
+			out = append(out, t)
+		}
+	}
+	return out
+}
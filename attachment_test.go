@@ -0,0 +1,104 @@
+package maxigobot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+func attachmentUpdate(raw ...string) *maxigo.MessageCreatedUpdate {
+	atts := make([]json.RawMessage, len(raw))
+	for i, r := range raw {
+		atts[i] = json.RawMessage(r)
+	}
+	return &maxigo.MessageCreatedUpdate{
+		Message: maxigo.Message{Body: maxigo.MessageBody{Attachments: atts}},
+	}
+}
+
+func TestNativeContext_Attachments(t *testing.T) {
+	ctx := newTestContext(newTestBot(), attachmentUpdate(
+		`{"type":"image","payload":{"photo_id":1,"url":"https://a/1.jpg"}}`,
+		`{"type":"unknown_future_type"}`,
+		`{"type":"file","payload":{"url":"https://a/doc.pdf"},"filename":"doc.pdf","size":42}`,
+		`{"type":"image","payload":"malformed"}`,
+		`{"type":"location","latitude":55.75,"longitude":37.61}`,
+		`{"type":"image","payload":{"photo_id":2}}`,
+	))
+
+	if got := len(ctx.Attachments()); got != 4 {
+		t.Fatalf("Attachments() = %d items, want 4", got)
+	}
+
+	photos := ctx.Photos()
+	if len(photos) != 2 || photos[0].Payload.PhotoID != 1 || photos[1].Payload.PhotoID != 2 {
+		t.Errorf("Photos() = %+v", photos)
+	}
+
+	files := ctx.Files()
+	if len(files) != 1 || files[0].Filename != "doc.pdf" || files[0].Size != 42 {
+		t.Errorf("Files() = %+v", files)
+	}
+
+	loc := ctx.Location()
+	if loc == nil || loc.Latitude != 55.75 || loc.Longitude != 37.61 {
+		t.Errorf("Location() = %+v", loc)
+	}
+
+	if c, _ := ctx.Contact(); c != nil {
+		t.Errorf("Contact() = %+v, want nil", c)
+	}
+}
+
+func TestNativeContext_Attachments_noMessage(t *testing.T) {
+	ctx := newTestContext(newTestBot(), &maxigo.BotStartedUpdate{})
+	if ctx.Attachments() != nil || ctx.Photos() != nil || ctx.Files() != nil || ctx.Location() != nil {
+		t.Error("accessors should return nil without a message")
+	}
+}
+
+func TestNativeContext_Contact(t *testing.T) {
+	const vcf = "BEGIN:VCARD\nTEL:+79990001122\nEND:VCARD"
+	mac := hmac.New(sha256.New, []byte("test-token"))
+	mac.Write([]byte(vcf))
+	hash := hex.EncodeToString(mac.Sum(nil))
+
+	contact := func(hash string) string {
+		payload, _ := json.Marshal(maxigo.ContactAttachmentPayload{VCFInfo: ptrString(vcf), Hash: hash})
+		return `{"type":"contact","payload":` + string(payload) + `}`
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		hash         string
+		wantVerified bool
+	}{
+		{"valid hash", "test-token", hash, true},
+		{"no hash", "test-token", "", false},
+		{"forged hash", "test-token", hex.EncodeToString(make([]byte, sha256.Size)), false},
+		{"other bot token", "other-token", hash, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := newTestBot()
+			bot.token = tt.token
+			ctx := newTestContext(bot, attachmentUpdate(contact(tt.hash)))
+
+			c, verified := ctx.Contact()
+			if c == nil {
+				t.Fatal("Contact() should not be nil")
+			}
+			if c.Payload.Phone() != "+79990001122" {
+				t.Errorf("Phone() = %q", c.Payload.Phone())
+			}
+			if verified != tt.wantVerified {
+				t.Errorf("verified = %v, want %v", verified, tt.wantVerified)
+			}
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"runtime/debug"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	maxigo "github.com/maxigo-bot/maxigo-client"
 )
@@ -17,20 +19,43 @@ var ErrAlreadyStarted = errors.New("maxigobot: bot is already started")
 
 // Bot is the main framework entry point.
 type Bot struct {
+	token         string
 	client        *maxigo.Client
 	poller        Poller
 	handlers      map[string]*handlerEntry
+	patterns      []*patternEntry
 	preMiddleware []MiddlewareFunc
 	useMiddleware []MiddlewareFunc
 	groups        []*Group
 	updateTypes   []string
+	states        StateStorage
+	callbackSep   string
 	stop          chan struct{}
 	stopOnce      sync.Once
 	wg            sync.WaitGroup
 	ctx           gocontext.Context
 	cancel        gocontext.CancelFunc
-	started atomic.Bool
-	retry   retryConfig
+	done          chan struct{} // closed when Start returns
+	abandon       chan struct{} // closed when Shutdown gives up waiting
+	abandonOnce   sync.Once
+	started       atomic.Bool
+	retry         retryConfig
+	limit         *rateLimiter
+	fileCache     FileCache
+	downloadLimit int64
+	downloader    *http.Client
+	jobStore      JobStore
+	sched         *scheduler
+	schedOnce     sync.Once
+
+	handlerTimeout time.Duration
+
+	workers     int
+	queueSize   int
+	ordering    Ordering
+	queue       atomic.Pointer[chan any]
+	inFlight    atomic.Int64
+	partitioned atomic.Int64
 
 	// OnError is called when a handler returns an error or a panic is recovered.
 	// The Context argument may be nil for infrastructure errors (poller failures,
@@ -47,11 +72,17 @@ func New(token string, opts ...Option) (*Bot, error) {
 
 	ctx, cancel := gocontext.WithCancel(gocontext.Background())
 	b := &Bot{
-		handlers:       make(map[string]*handlerEntry),
-		stop:           make(chan struct{}),
-		ctx:            ctx,
-		cancel:         cancel,
+		token:       token,
+		handlers:    make(map[string]*handlerEntry),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		abandon:     make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+		callbackSep: DefaultCallbackSeparator,
+		fileCache:   NewMemoryFileCache(),
 		retry: retryConfig{
+			rateLimitIntervals:   DefaultRateLimitIntervals,
 			uploadRetryIntervals: DefaultUploadRetryIntervals,
 		},
 	}
@@ -99,14 +130,41 @@ func (b *Bot) Use(middleware ...MiddlewareFunc) {
 	b.useMiddleware = append(b.useMiddleware, middleware...)
 }
 
-// Handle registers a handler for the given endpoint.
+// Handle registers a handler for the given endpoint: a command ("/start"),
+// an event constant (OnText, ...), OnCallback(...), a callback button
+// (maxigo.Button or *maxigo.Button), or a text pattern ([TextMatcher] or
+// *regexp.Regexp).
 // Optional per-handler middleware is applied after global and group middleware.
+//
+// Registering a handler for an endpoint replaces the previous one.
+// Use HandleFiltered to add handlers that only accept some updates.
 func (b *Bot) Handle(endpoint any, h HandlerFunc, m ...MiddlewareFunc) {
+	if tm, ok := textMatcher(endpoint); ok {
+		b.patterns = addPattern(b.patterns, tm, h, m)
+		return
+	}
 	key := endpointKey(endpoint)
-	b.handlers[key] = &handlerEntry{
-		handler:    h,
-		middleware: m,
+	b.checkCallbackConflict(key)
+	addHandler(b.handlers, key, h, m)
+}
+
+// HandleFiltered registers a handler for the given endpoint that only
+// handles the updates accepted by f, e.g. fsm.InState:
+//
+//	b.HandleFiltered(maxigobot.OnText, emailHandler, fsm.InState("await_email"))
+//	b.Handle(maxigobot.OnText, fallbackHandler)
+//
+// Filtered handlers are kept alongside the handler registered with Handle
+// and tried first, in registration order. The filter runs after global and
+// group middleware and before the per-handler middleware m.
+func (b *Bot) HandleFiltered(endpoint any, f Filter, h HandlerFunc, m ...MiddlewareFunc) {
+	if tm, ok := textMatcher(endpoint); ok {
+		b.patterns = addPattern(b.patterns, tm, h, filterMiddleware(f, m))
+		return
 	}
+	key := endpointKey(endpoint)
+	b.checkCallbackConflict(key)
+	addFiltered(b.handlers, key, f, h, m)
 }
 
 // Group creates a new handler group with an isolated middleware stack.
@@ -119,34 +177,96 @@ func (b *Bot) Group() *Group {
 	return g
 }
 
-// Start begins polling for updates and dispatching them to handlers.
-// This method blocks until Stop() is called, the poller finishes,
-// and all in-flight handlers complete.
+// Start begins polling for updates and dispatching them to handlers, and
+// runs scheduled jobs (see ScheduleAt). This method blocks until Stop() or
+// Shutdown() is called, the poller finishes, and all in-flight handlers and
+// jobs complete, or until Shutdown gives up waiting for them.
 // Panics if called more than once.
 func (b *Bot) Start() {
 	if !b.started.CompareAndSwap(false, true) {
 		panic(ErrAlreadyStarted)
 	}
 
-	updates := make(chan any, 100)
+	size := b.queueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	updates := make(chan any, size)
+	b.queue.Store(&updates)
 	go b.poller.Poll(b, updates, b.stop)
 
-	for upd := range updates {
-		b.wg.Add(1)
-		go func(u any) {
-			defer b.wg.Done()
-			b.processUpdate(u)
-		}(upd)
-	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.runScheduler()
+	}()
 
-	b.wg.Wait()
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		b.dispatch(updates)
+		b.wg.Wait()
+	}()
+
+	select {
+	case <-finished:
+		b.flushDeletes()
+	case <-b.abandon:
+		// Shutdown deadline: leave the remaining handlers behind.
+	}
+	close(b.done)
 }
 
-// Stop signals the poller to stop and shuts down the bot.
+// Stop signals the poller to stop and cancels the context of in-flight
+// handlers and jobs immediately. Use Shutdown to let them finish first.
 // Safe to call multiple times.
 func (b *Bot) Stop() {
-	b.stopOnce.Do(func() {
+	b.stopIntake()
+	b.cancel()
+}
+
+// Shutdown stops the bot gracefully: the poller stops receiving updates, and
+// updates already received, in-flight handlers and running jobs are allowed
+// to finish while Shutdown waits for Start to return.
+//
+// If ctx is done first, Shutdown cancels the handlers' contexts
+// (Context.Ctx), makes Start return without waiting for them, and returns
+// the number of updates that were still queued or being handled, together
+// with ctx.Err(). Abandoned handlers keep running in the background until
+// they notice the cancellation. Pending WithDeleteAfter deletions are then
+// not performed on the way out; with an in-memory job store they are lost.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if n, err := b.Shutdown(ctx); err != nil {
+//		log.Printf("shutdown: %d updates abandoned: %v", n, err)
+//	}
+//
+// Shutdown returns immediately if the bot was not started. It is safe to
+// call multiple times and together with Stop.
+func (b *Bot) Shutdown(ctx gocontext.Context) (abandoned int, err error) {
+	b.stopIntake()
+	if !b.started.Load() {
 		b.cancel()
+		return 0, nil
+	}
+
+	select {
+	case <-b.done:
+		b.cancel()
+		return 0, nil
+	case <-ctx.Done():
+	}
+
+	stats := b.Stats()
+	b.abandonOnce.Do(func() { close(b.abandon) })
+	b.cancel()
+	return stats.Queued + stats.InFlight, ctx.Err()
+}
+
+// stopIntake stops the poller without canceling in-flight handlers.
+func (b *Bot) stopIntake() {
+	b.stopOnce.Do(func() {
 		close(b.stop)
 	})
 }
@@ -165,34 +285,58 @@ func (b *Bot) processUpdate(update any) {
 		return
 	}
 
+	meta := extractMeta(update)
+	info := UpdateInfo{UpdateType: meta.base.UpdateType, ChatID: meta.chatID, Endpoint: endpoint}
+	if meta.sender != nil {
+		info.UserID = meta.sender.UserID
+	}
+	reqCtx := withUpdateInfo(b.ctx, info)
+	if b.handlerTimeout > 0 {
+		var cancel gocontext.CancelFunc
+		reqCtx, cancel = gocontext.WithTimeout(reqCtx, b.handlerTimeout)
+		defer cancel()
+	}
+
 	ctx := &nativeContext{
 		bot:     b,
 		update:  update,
-		meta:    extractMeta(update),
+		meta:    meta,
 		command: cmd,
 		payload: payload,
-		ctx:     b.ctx,
+		ctx:     reqCtx,
 	}
 
 	// Pre-middleware runs on all updates.
 	preHandler := HandlerFunc(func(c Context) error {
-		entry, groupMW := b.findHandler(endpoint, update)
-		if entry == nil {
+		cands := b.findHandlers(endpoint, ctx.update)
+		if len(cands) == 0 {
 			return nil // No handler registered — skip.
 		}
 
+		// Try the candidates in order until one accepts the update.
 		// Build handler chain: Use → Group → Per-handler → Handler.
-		h := entry.handler
-		h = applyMiddleware(h, entry.middleware...)
-		h = applyMiddleware(h, groupMW...)
+		h := HandlerFunc(func(c Context) error {
+			for _, cand := range cands {
+				ctx.match = cand.match
+				ctx.callbackArgs = cand.callbackArgs
+				err := applyMiddleware(cand.entry.run, cand.groupMW...)(c)
+				if !errors.Is(err, ErrSkip) {
+					return err
+				}
+			}
+			return nil // Every handler declined the update.
+		})
 		h = applyMiddleware(h, b.useMiddleware...)
 
-		return h(c)
+		if err := h(c); !errors.Is(err, ErrSkip) {
+			return err
+		}
+		return nil // Use middleware declined the update.
 	})
 
 	chain := applyMiddleware(preHandler, b.preMiddleware...)
 
-	if err := chain(ctx); err != nil {
+	if err := TimeoutError(reqCtx, b.ctx, chain(ctx)); err != nil {
 		b.handleError(err, ctx, endpoint)
 	}
 }
@@ -1,7 +1,9 @@
 package maxigobot
 
 import (
+	gocontext "context"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -24,12 +26,18 @@ func TestNew_defaults(t *testing.T) {
 	if b.client == nil {
 		t.Error("client should not be nil")
 	}
+	if b.token != "test-token" {
+		t.Errorf("token = %q, want %q", b.token, "test-token")
+	}
 	if b.poller == nil {
 		t.Error("poller should not be nil")
 	}
 	if _, ok := b.poller.(*LongPoller); !ok {
 		t.Error("default poller should be LongPoller")
 	}
+	if len(b.retry.rateLimitIntervals) != len(DefaultRateLimitIntervals) {
+		t.Error("rate limit retries should default to DefaultRateLimitIntervals")
+	}
 }
 
 func TestNew_withClient(t *testing.T) {
@@ -85,6 +93,26 @@ func TestBot_Handle(t *testing.T) {
 	}
 }
 
+func TestBot_Handle_replacesWithMiddleware(t *testing.T) {
+	b, _ := New("token")
+
+	var got []string
+	logMW := func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			got = append(got, "mw")
+			return next(c)
+		}
+	}
+	b.Handle("/x", func(c Context) error { got = append(got, "h1"); return nil }, logMW)
+	b.Handle("/x", func(c Context) error { got = append(got, "h2"); return nil })
+
+	b.processUpdate(textUpdate(1, 1, "/x"))
+
+	if len(got) != 1 || got[0] != "h2" {
+		t.Errorf("calls = %v, want [h2]", got)
+	}
+}
+
 func TestBot_PreUse(t *testing.T) {
 	b, _ := New("token")
 
@@ -382,3 +410,102 @@ func (p *mockPoller) Poll(_ *Bot, updates chan<- any, stop chan struct{}) {
 	}
 	<-stop
 }
+
+// runStart runs b.Start in the background and returns a channel closed when it returns.
+func runStart(b *Bot) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		b.Start()
+		close(done)
+	}()
+	return done
+}
+
+func TestBot_Shutdown_waitsForHandlers(t *testing.T) {
+	b, _ := New("token")
+	b.poller = &mockPoller{updates: []any{textUpdate(1, 1, "hi")}}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var ctxErr error
+	b.Handle(OnText, func(c Context) error {
+		close(started)
+		<-release
+		ctxErr = c.Ctx().Err()
+		return nil
+	})
+	done := runStart(b)
+	waitFor(t, started)
+
+	result := make(chan error, 1)
+	go func() {
+		n, err := b.Shutdown(gocontext.Background())
+		if n != 0 {
+			err = fmt.Errorf("abandoned = %d, want 0", n)
+		}
+		result <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Start returned before the handler finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not return")
+	}
+	waitFor(t, done)
+	if ctxErr != nil {
+		t.Errorf("handler context error = %v, want nil during graceful shutdown", ctxErr)
+	}
+	if b.ctx.Err() == nil {
+		t.Error("bot context should be canceled after Shutdown")
+	}
+}
+
+func TestBot_Shutdown_deadline(t *testing.T) {
+	b, _ := New("token", WithWorkers(1))
+	b.poller = &mockPoller{updates: []any{textUpdate(1, 1, "a"), textUpdate(1, 1, "b")}}
+
+	started, stuck := make(chan struct{}), make(chan struct{})
+	canceled := make(chan struct{})
+	b.Handle(OnText, func(c Context) error {
+		close(started)
+		<-c.Ctx().Done()
+		close(canceled)
+		<-stuck // Ignores cancellation.
+		return nil
+	})
+	defer close(stuck)
+	done := runStart(b)
+	waitFor(t, started)
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 20*time.Millisecond)
+	defer cancel()
+	n, err := b.Shutdown(ctx)
+	if !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+	if n != 2 {
+		t.Errorf("abandoned = %d, want 2 (one in flight, one queued)", n)
+	}
+	waitFor(t, canceled)
+	waitFor(t, done)
+}
+
+func TestBot_Shutdown_notStarted(t *testing.T) {
+	b, _ := New("token")
+	if n, err := b.Shutdown(gocontext.Background()); n != 0 || err != nil {
+		t.Errorf("Shutdown = %d, %v; want 0, nil", n, err)
+	}
+	if b.ctx.Err() == nil {
+		t.Error("bot context should be canceled")
+	}
+	b.Stop() // Must not panic after Shutdown.
+}
@@ -0,0 +1,296 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/maxigo-bot/maxigo-bot/internal/fileutil"
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+const (
+	// defaultBroadcastRate is the default broadcast rate in messages per second,
+	// kept below the Max API limit of 30 requests per second.
+	defaultBroadcastRate = 25
+	// defaultBroadcastWorkers is the default number of concurrent sends.
+	defaultBroadcastWorkers = 4
+	// defaultCheckpointEvery is the default number of recipients between checkpoints.
+	defaultCheckpointEvery = 100
+)
+
+// IsUnreachable reports whether err means the recipient can no longer be
+// messaged: the bot was blocked or removed (HTTP 403) or the chat does not
+// exist (HTTP 404). Such recipients are usually removed from mailing lists.
+func IsUnreachable(err error) bool {
+	var e *maxigo.Error
+	if !errors.As(err, &e) || e.Kind != maxigo.ErrAPI {
+		return false
+	}
+	return e.StatusCode == http.StatusForbidden || e.StatusCode == http.StatusNotFound
+}
+
+// BroadcastProgress reports the state of a broadcast.
+type BroadcastProgress struct {
+	// Skipped is the number of recipients skipped because a previous run
+	// already processed them (see Broadcaster.Checkpoint).
+	Skipped int
+	// Sent is the number of messages delivered.
+	Sent int
+	// Unreachable is the number of recipients that blocked the bot or no
+	// longer exist (see IsUnreachable).
+	Unreachable int
+	// Failed is the number of recipients that failed for other reasons,
+	// after retries were exhausted.
+	Failed int
+}
+
+// Processed returns the number of recipients handled so far, including skipped ones.
+func (p BroadcastProgress) Processed() int {
+	return p.Skipped + p.Sent + p.Unreachable + p.Failed
+}
+
+// BroadcastCheckpoint persists how many recipients of a broadcast have been
+// processed, so that an interrupted broadcast can resume where it stopped.
+type BroadcastCheckpoint interface {
+	// Load returns the number of processed recipients (0 for a new broadcast).
+	Load() (int, error)
+	// Save stores the number of processed recipients.
+	Save(processed int) error
+}
+
+// Broadcaster sends a message to many chats with rate limiting, retries,
+// progress reporting and resumable checkpoints.
+//
+//	bc := &maxigobot.Broadcaster{
+//		Bot:        b,
+//		Checkpoint: maxigobot.NewFileCheckpoint("announce.checkpoint"),
+//		OnFailure: func(chatID int64, err error) {
+//			if maxigobot.IsUnreachable(err) {
+//				db.Unsubscribe(chatID)
+//			}
+//		},
+//	}
+//	progress, err := bc.Run(ctx, db.Subscribers(), "Big news!")
+//
+// Sends also go through the bot's own rate limiter (see WithRateLimit) and
+// the retry policy configured on the bot.
+type Broadcaster struct {
+	// Bot is the bot used to send messages. Required.
+	Bot *Bot
+	// Rate is the maximum number of messages per second (default 25).
+	Rate float64
+	// Workers is the number of concurrent sends (default 4).
+	Workers int
+	// Checkpoint, if set, is loaded before the broadcast to skip already
+	// processed recipients and saved as the broadcast progresses.
+	// The chat sequence must yield recipients in the same order on every run.
+	// Concurrent sends complete out of order, so after a crash up to
+	// CheckpointEvery+Workers recipients may receive the message twice.
+	Checkpoint BroadcastCheckpoint
+	// CheckpointEvery is the number of processed recipients between
+	// checkpoint saves (default 100). The checkpoint is also saved when
+	// the broadcast finishes or is canceled.
+	CheckpointEvery int
+	// OnProgress, if set, is called after each processed recipient.
+	// It is called from a single goroutine.
+	OnProgress func(BroadcastProgress)
+	// OnFailure, if set, is called for each recipient that could not be
+	// messaged. It is called from a single goroutine.
+	OnFailure func(chatID int64, err error)
+}
+
+type broadcastJob struct {
+	index  int
+	chatID int64
+}
+
+type broadcastResult struct {
+	broadcastJob
+	err error
+}
+
+// Run sends text with opts to every chat yielded by chats and returns the
+// final progress. Per-recipient failures do not stop the broadcast; they
+// are reported to OnFailure and counted in the progress.
+//
+// Run returns ctx.Err() if ctx is canceled. Recipients interrupted by the
+// cancellation are not counted as processed, so a resumed run sends to them.
+func (bc *Broadcaster) Run(ctx gocontext.Context, chats iter.Seq[int64], text string, opts ...SendOption) (BroadcastProgress, error) {
+	var progress BroadcastProgress
+	if bc.Bot == nil {
+		return progress, &BotError{Err: errors.New("maxigobot: broadcaster has no bot")}
+	}
+
+	if bc.Checkpoint != nil {
+		n, err := bc.Checkpoint.Load()
+		if err != nil {
+			return progress, fmt.Errorf("maxigobot: load broadcast checkpoint: %w", err)
+		}
+		progress.Skipped = n
+	}
+
+	rate := bc.Rate
+	if rate <= 0 {
+		rate = defaultBroadcastRate
+	}
+	workers := bc.Workers
+	if workers <= 0 {
+		workers = defaultBroadcastWorkers
+	}
+	every := bc.CheckpointEvery
+	if every <= 0 {
+		every = defaultCheckpointEvery
+	}
+
+	// Media are uploaded once and the tokens are reused for every recipient.
+	cfg := buildSendConfig(opts)
+	if err := bc.Bot.uploadMedia(ctx, 0, &cfg); err != nil {
+		return progress, err
+	}
+	limiter := &rateLimiter{global: newTokenBucket(rate, 1), now: time.Now}
+	body := toMessageBody(text, cfg)
+
+	jobs := make(chan broadcastJob)
+	results := make(chan broadcastResult)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				err := limiter.wait(ctx, 0)
+				if err == nil {
+					err = bc.Bot.call(ctx, j.chatID, func() error {
+						_, err := bc.Bot.client.SendMessage(ctx, j.chatID, body)
+						return err
+					})
+				}
+				results <- broadcastResult{j, err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(results)
+		defer wg.Wait()
+		defer close(jobs)
+
+		index := 0
+		for chatID := range chats {
+			if index < progress.Skipped {
+				index++
+				continue
+			}
+			select {
+			case jobs <- broadcastJob{index, chatID}:
+			case <-ctx.Done():
+				return
+			}
+			index++
+		}
+	}()
+
+	// watermark is the number of leading recipients that have all been
+	// processed; done holds completed indexes beyond it. Workers finish out
+	// of order, so only the watermark is safe to checkpoint.
+	watermark := progress.Skipped
+	saved := watermark
+	done := make(map[int]bool)
+	var saveErr error
+
+	save := func() {
+		if bc.Checkpoint == nil || watermark == saved || saveErr != nil {
+			return
+		}
+		if err := bc.Checkpoint.Save(watermark); err != nil {
+			saveErr = fmt.Errorf("maxigobot: save broadcast checkpoint: %w", err)
+			return
+		}
+		saved = watermark
+	}
+
+	for r := range results {
+		if r.err != nil && ctx.Err() != nil {
+			continue // Interrupted by cancellation, not processed.
+		}
+
+		switch {
+		case r.err == nil:
+			progress.Sent++
+		case IsUnreachable(r.err):
+			progress.Unreachable++
+		default:
+			progress.Failed++
+		}
+		if r.err != nil && bc.OnFailure != nil {
+			bc.OnFailure(r.chatID, r.err)
+		}
+		if bc.OnProgress != nil {
+			bc.OnProgress(progress)
+		}
+
+		done[r.index] = true
+		for done[watermark] {
+			delete(done, watermark)
+			watermark++
+		}
+		if watermark-saved >= every {
+			save()
+		}
+	}
+	save()
+
+	if err := ctx.Err(); err != nil {
+		return progress, err
+	}
+	return progress, saveErr
+}
+
+// FileCheckpoint is a BroadcastCheckpoint stored in a JSON file.
+// Remove the file to start the broadcast over.
+type FileCheckpoint struct {
+	path string
+}
+
+// NewFileCheckpoint returns a checkpoint stored at path.
+// The file is created on the first save.
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{path: path}
+}
+
+type checkpointFile struct {
+	Processed int `json:"processed"`
+}
+
+// Load implements BroadcastCheckpoint. A missing file means a new broadcast.
+func (f *FileCheckpoint) Load() (int, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var cp checkpointFile
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return 0, err
+	}
+	return cp.Processed, nil
+}
+
+// Save implements BroadcastCheckpoint. The file is replaced atomically.
+func (f *FileCheckpoint) Save(processed int) error {
+	data, err := json.Marshal(checkpointFile{Processed: processed})
+	if err != nil {
+		return err
+	}
+	return fileutil.WriteAtomic(f.path, data)
+}
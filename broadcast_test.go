@@ -0,0 +1,193 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// memCheckpoint is an in-memory BroadcastCheckpoint.
+type memCheckpoint struct {
+	processed int
+	saves     int
+}
+
+func (m *memCheckpoint) Load() (int, error) { return m.processed, nil }
+
+func (m *memCheckpoint) Save(n int) error {
+	m.processed = n
+	m.saves++
+	return nil
+}
+
+// broadcastServer answers SendMessage: 403 for chat 403, 404 for chat 404,
+// 500 for chat 500 and success otherwise. It records delivered chat IDs.
+func broadcastServer(t *testing.T, sent *[]int64, mu *sync.Mutex) *Bot {
+	return testBotWithServer(t, func(w http.ResponseWriter, r *http.Request) {
+		chatID, _ := strconv.ParseInt(r.URL.Query().Get("chat_id"), 10, 64)
+		switch chatID {
+		case 403, 404, 500:
+			w.WriteHeader(int(chatID))
+			_, _ = w.Write([]byte(`{"code":"error","message":"failed"}`))
+			return
+		}
+		mu.Lock()
+		*sent = append(*sent, chatID)
+		mu.Unlock()
+		writeJSON(t, w, `{"message":{"recipient":{"chat_id":1,"chat_type":"dialog"},"timestamp":1,"body":{"mid":"m","seq":1}}}`)
+	})
+}
+
+func TestBroadcaster_Run(t *testing.T) {
+	var (
+		sent []int64
+		mu   sync.Mutex
+	)
+	b := broadcastServer(t, &sent, &mu)
+
+	failures := make(map[int64]bool)
+	var progressCalls int
+	bc := &Broadcaster{
+		Bot:  b,
+		Rate: 1000,
+		OnFailure: func(chatID int64, err error) {
+			failures[chatID] = IsUnreachable(err)
+		},
+		OnProgress: func(BroadcastProgress) { progressCalls++ },
+	}
+
+	chats := []int64{1, 403, 2, 404, 3, 500}
+	p, err := bc.Run(gocontext.Background(), slices.Values(chats), "news")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := BroadcastProgress{Sent: 3, Unreachable: 2, Failed: 1}
+	if p != want {
+		t.Errorf("progress = %+v, want %+v", p, want)
+	}
+	if p.Processed() != len(chats) || progressCalls != len(chats) {
+		t.Errorf("Processed() = %d, progress calls = %d, want %d", p.Processed(), progressCalls, len(chats))
+	}
+	slices.Sort(sent)
+	if !slices.Equal(sent, []int64{1, 2, 3}) {
+		t.Errorf("sent to %v, want [1 2 3]", sent)
+	}
+	if len(failures) != 3 || !failures[403] || !failures[404] || failures[500] {
+		t.Errorf("failures = %v", failures)
+	}
+}
+
+func TestBroadcaster_resume(t *testing.T) {
+	var (
+		sent []int64
+		mu   sync.Mutex
+	)
+	b := broadcastServer(t, &sent, &mu)
+
+	cp := &memCheckpoint{processed: 3}
+	bc := &Broadcaster{Bot: b, Rate: 1000, Workers: 2, Checkpoint: cp, CheckpointEvery: 2}
+
+	p, err := bc.Run(gocontext.Background(), slices.Values([]int64{1, 2, 3, 4, 5, 6, 7}), "news")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if p.Skipped != 3 || p.Sent != 4 {
+		t.Errorf("progress = %+v, want 3 skipped and 4 sent", p)
+	}
+	slices.Sort(sent)
+	if !slices.Equal(sent, []int64{4, 5, 6, 7}) {
+		t.Errorf("sent to %v, want [4 5 6 7]", sent)
+	}
+	if cp.processed != 7 {
+		t.Errorf("checkpoint = %d, want 7", cp.processed)
+	}
+	if cp.saves < 2 {
+		t.Errorf("checkpoint saved %d times, want periodic saves", cp.saves)
+	}
+}
+
+func TestBroadcaster_cancel(t *testing.T) {
+	var (
+		sent []int64
+		mu   sync.Mutex
+	)
+	b := broadcastServer(t, &sent, &mu)
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	defer cancel()
+
+	cp := &memCheckpoint{}
+	bc := &Broadcaster{
+		Bot:        b,
+		Rate:       1000,
+		Workers:    1,
+		Checkpoint: cp,
+		OnProgress: func(p BroadcastProgress) {
+			if p.Sent == 2 {
+				cancel()
+			}
+		},
+	}
+
+	chats := func(yield func(int64) bool) {
+		for id := int64(1); ; id++ {
+			if !yield(id) {
+				return
+			}
+		}
+	}
+	p, err := bc.Run(ctx, chats, "news")
+	if !errors.Is(err, gocontext.Canceled) {
+		t.Fatalf("Run = %v, want Canceled", err)
+	}
+	if cp.processed != p.Processed() || p.Processed() < 2 {
+		t.Errorf("checkpoint = %d, progress = %+v", cp.processed, p)
+	}
+}
+
+func TestBroadcaster_noBot(t *testing.T) {
+	if _, err := (&Broadcaster{}).Run(gocontext.Background(), slices.Values([]int64{1}), "x"); err == nil {
+		t.Error("expected error without bot")
+	}
+}
+
+func TestFileCheckpoint(t *testing.T) {
+	cp := NewFileCheckpoint(filepath.Join(t.TempDir(), "cp.json"))
+
+	n, err := cp.Load()
+	if err != nil || n != 0 {
+		t.Fatalf("Load new = %d, %v; want 0, nil", n, err)
+	}
+	if err := cp.Save(42); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if n, err := cp.Load(); err != nil || n != 42 {
+		t.Errorf("Load = %d, %v; want 42, nil", n, err)
+	}
+}
+
+func TestIsUnreachable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&maxigo.Error{Kind: maxigo.ErrAPI, StatusCode: 403}, true},
+		{&maxigo.Error{Kind: maxigo.ErrAPI, StatusCode: 404}, true},
+		{&maxigo.Error{Kind: maxigo.ErrAPI, StatusCode: 500}, false},
+		{&maxigo.Error{Kind: maxigo.ErrNetwork}, false},
+		{errors.New("other"), false},
+	}
+	for _, tt := range tests {
+		if got := IsUnreachable(tt.err); got != tt.want {
+			t.Errorf("IsUnreachable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
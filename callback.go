@@ -0,0 +1,90 @@
+package maxigobot
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCallbackSeparator separates the unique part of a callback payload
+// from its arguments, e.g. "buy:42:blue".
+const DefaultCallbackSeparator = ":"
+
+// WithCallbackSeparator sets the separator used to split callback payloads
+// into a unique prefix and arguments. With the default ":" a handler
+// registered for OnCallback("buy") receives "buy:42:blue" with
+// c.CallbackArgs() == ["42", "blue"]. An empty separator disables prefix
+// matching, so callbacks only match their full payload.
+func WithCallbackSeparator(sep string) Option {
+	return func(b *Bot) {
+		b.callbackSep = sep
+	}
+}
+
+// splitCallback splits a callback payload into its unique prefix and arguments.
+// Returns ok == false if the payload contains no separator.
+func splitCallback(payload, sep string) (unique string, args []string, ok bool) {
+	if sep == "" {
+		return "", nil, false
+	}
+	unique, rest, ok := strings.Cut(payload, sep)
+	if !ok {
+		return "", nil, false
+	}
+	return unique, strings.Split(rest, sep), true
+}
+
+// lookup returns the handlers registered for the exact endpoint,
+// in groups first and then bot handlers.
+func (b *Bot) lookup(endpoint string) []routeCandidate {
+	var cands []routeCandidate
+	for _, g := range b.groups {
+		if entry, ok := g.handlers[endpoint]; ok {
+			cands = append(cands, routeCandidate{entry: entry, groupMW: g.middleware})
+		}
+	}
+	if entry, ok := b.handlers[endpoint]; ok {
+		cands = append(cands, routeCandidate{entry: entry})
+	}
+	return cands
+}
+
+// callbackPrefixHandlers returns the handlers for the unique prefix before
+// the separator of a callback payload, with the payload arguments.
+func (b *Bot) callbackPrefixHandlers(payload string) []routeCandidate {
+	unique, args, ok := splitCallback(payload, b.callbackSep)
+	if !ok {
+		return nil
+	}
+	cands := b.lookup(OnCallback(unique))
+	for i := range cands {
+		cands[i].callbackArgs = args
+	}
+	return cands
+}
+
+// checkCallbackConflict panics if key is a callback endpoint that overlaps
+// with an already registered one: with separator ":", OnCallback("buy") and
+// OnCallback("buy:42") would both claim the payload "buy:42".
+// Called at setup time from Handle.
+func (b *Bot) checkCallbackConflict(key string) {
+	unique, ok := strings.CutPrefix(key, callbackPrefix)
+	if !ok || unique == "" || b.callbackSep == "" {
+		return
+	}
+
+	check := func(handlers map[string]*handlerEntry) {
+		for k := range handlers {
+			other, ok := strings.CutPrefix(k, callbackPrefix)
+			if !ok || other == "" || other == unique {
+				continue
+			}
+			if strings.HasPrefix(unique, other+b.callbackSep) || strings.HasPrefix(other, unique+b.callbackSep) {
+				panic(fmt.Sprintf("maxigobot: callback endpoint %q conflicts with %q (separator %q)", unique, other, b.callbackSep))
+			}
+		}
+	}
+	check(b.handlers)
+	for _, g := range b.groups {
+		check(g.handlers)
+	}
+}
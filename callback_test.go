@@ -0,0 +1,160 @@
+package maxigobot
+
+import (
+	"slices"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+func callbackUpdate(payload string) *maxigo.MessageCallbackUpdate {
+	return &maxigo.MessageCallbackUpdate{Callback: maxigo.Callback{Payload: payload}}
+}
+
+// firstRoute returns the first handler found for endpoint and records its
+// routing results on c.
+func firstRoute(b *Bot, c *nativeContext, endpoint string) (*handlerEntry, []MiddlewareFunc) {
+	cands := b.findHandlers(endpoint, c.update)
+	if len(cands) == 0 {
+		return nil, nil
+	}
+	c.match = cands[0].match
+	c.callbackArgs = cands[0].callbackArgs
+	return cands[0].entry, cands[0].groupMW
+}
+
+func TestRoute_callbackArgs(t *testing.T) {
+	b, _ := New("token")
+	var got string
+	b.Handle(OnCallback("buy"), func(c Context) error { got = "buy"; return nil })
+	b.Handle(OnCallback("menu"), func(c Context) error { got = "menu"; return nil })
+	b.Handle(OnCallback(""), func(c Context) error { got = "any"; return nil })
+
+	tests := []struct {
+		payload  string
+		wantName string
+		wantArgs []string
+	}{
+		{"buy", "buy", nil},
+		{"buy:42:blue", "buy", []string{"42", "blue"}},
+		{"buy:", "buy", []string{""}},
+		{"menu:settings", "menu", []string{"settings"}},
+		{"sell:1", "any", nil},
+		{"other", "any", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.payload, func(t *testing.T) {
+			got = ""
+			ctx := newTestContext(b, callbackUpdate(tt.payload))
+			entry, _ := firstRoute(b, ctx, OnCallback(tt.payload))
+			if entry == nil {
+				t.Fatal("no handler found")
+			}
+			_ = entry.run(ctx)
+			if got != tt.wantName {
+				t.Errorf("handler = %q, want %q", got, tt.wantName)
+			}
+			if !slices.Equal(ctx.CallbackArgs(), tt.wantArgs) {
+				t.Errorf("CallbackArgs() = %q, want %q", ctx.CallbackArgs(), tt.wantArgs)
+			}
+			if ctx.Data() != tt.payload {
+				t.Errorf("Data() = %q, want %q", ctx.Data(), tt.payload)
+			}
+		})
+	}
+}
+
+func TestRoute_callbackExactWins(t *testing.T) {
+	b, _ := New("token", WithCallbackSeparator("|"))
+	var got string
+	b.Handle(OnCallback("buy:42"), func(c Context) error { got = "exact"; return nil })
+	b.Handle(OnCallback("buy"), func(c Context) error { got = "prefix"; return nil })
+
+	ctx := newTestContext(b, callbackUpdate("buy:42"))
+	entry, _ := firstRoute(b, ctx, OnCallback("buy:42"))
+	_ = entry.run(ctx)
+	if got != "exact" {
+		t.Errorf("handler = %q, want exact", got)
+	}
+
+	ctx = newTestContext(b, callbackUpdate("buy|7"))
+	entry, _ = firstRoute(b, ctx, OnCallback("buy|7"))
+	_ = entry.run(ctx)
+	if got != "prefix" || !slices.Equal(ctx.CallbackArgs(), []string{"7"}) {
+		t.Errorf("handler = %q, args = %q, want prefix with [7]", got, ctx.CallbackArgs())
+	}
+}
+
+func TestRoute_callbackGroup(t *testing.T) {
+	b, _ := New("token")
+	var mwCalled bool
+	g := b.Group()
+	g.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error { mwCalled = true; return next(c) }
+	})
+	g.Handle(OnCallback("page"), func(c Context) error { return nil })
+
+	ctx := newTestContext(b, callbackUpdate("page:3"))
+	entry, groupMW := firstRoute(b, ctx, OnCallback("page:3"))
+	if entry == nil {
+		t.Fatal("no handler found")
+	}
+	_ = applyMiddleware(entry.run, groupMW...)(ctx)
+	if !mwCalled {
+		t.Error("group middleware not applied")
+	}
+	if !slices.Equal(ctx.CallbackArgs(), []string{"3"}) {
+		t.Errorf("CallbackArgs() = %q, want [3]", ctx.CallbackArgs())
+	}
+}
+
+func TestRoute_callbackSeparatorDisabled(t *testing.T) {
+	b, _ := New("token", WithCallbackSeparator(""))
+	b.Handle(OnCallback("buy"), func(c Context) error { return nil })
+
+	ctx := newTestContext(b, callbackUpdate("buy:1"))
+	if entry, _ := firstRoute(b, ctx, OnCallback("buy:1")); entry != nil {
+		t.Error("prefix routing should be disabled")
+	}
+}
+
+func TestHandle_callbackConflict(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  string
+		second string
+		group  bool
+	}{
+		{"longer after shorter", "buy", "buy:42", false},
+		{"shorter after longer", "buy:42", "buy", false},
+		{"across group", "buy", "buy:42", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := New("token")
+			b.Handle(OnCallback(tt.first), func(c Context) error { return nil })
+
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic on conflicting callback endpoints")
+				}
+			}()
+			if tt.group {
+				b.Group().Handle(OnCallback(tt.second), func(c Context) error { return nil })
+			} else {
+				b.Handle(OnCallback(tt.second), func(c Context) error { return nil })
+			}
+		})
+	}
+}
+
+func TestHandle_callbackNoConflict(t *testing.T) {
+	b, _ := New("token")
+	b.Handle(OnCallback(""), func(c Context) error { return nil })
+	b.Handle(OnCallback("buy"), func(c Context) error { return nil })
+	b.Handle(OnCallback("buyer"), func(c Context) error { return nil })
+	b.Handle(OnCallback("buy"), func(c Context) error { return nil })
+	b.Group().Handle(OnCallback("sell"), func(c Context) error { return nil })
+}
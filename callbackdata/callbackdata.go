@@ -0,0 +1,316 @@
+// Package callbackdata encodes typed structs into compact callback button
+// payloads and decodes them back in handlers.
+//
+// A Codec is bound to a unique prefix, which is used for routing:
+//
+//	type Buy struct {
+//		ItemID int64
+//		Color  string
+//	}
+//
+//	var buy = callbackdata.New[Buy]("buy", callbackdata.WithSecret(secret))
+//
+//	btn, err := buy.Button("Buy", Buy{ItemID: 42, Color: "blue"}) // payload "buy:42:blue:<sig>"
+//
+//	b.Handle(buy.Endpoint(), buy.Handler(func(c maxigobot.Context, v Buy) error {
+//		return c.Respond("Buying " + v.Color)
+//	}))
+//
+// Fields are encoded in declaration order. Supported field kinds are strings,
+// booleans, integers and floats; fields tagged `callbackdata:"-"` and
+// unexported fields are skipped.
+package callbackdata
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// MaxPayloadLen is the maximum length of a callback button payload accepted by Max.
+const MaxPayloadLen = maxigobot.MaxButtonPayloadLen
+
+// signatureLen is the number of HMAC bytes kept in a signed payload.
+const signatureLen = 8
+
+// signedLen is the length of the encoded signature.
+var signedLen = base64.RawURLEncoding.EncodedLen(signatureLen)
+
+var (
+	// ErrTooLong is returned when an encoded payload exceeds MaxPayloadLen.
+	ErrTooLong = errors.New("callbackdata: payload too long")
+	// ErrMalformed is returned when a payload does not match the codec.
+	ErrMalformed = errors.New("callbackdata: malformed payload")
+	// ErrBadSignature is returned when a signed payload fails verification.
+	ErrBadSignature = errors.New("callbackdata: invalid signature")
+)
+
+// Option configures a Codec.
+type Option func(*config)
+
+type config struct {
+	sep    string
+	secret []byte
+}
+
+// WithSeparator sets the field separator. It must match the bot's
+// callback separator (see maxigobot.WithCallbackSeparator) so that payloads
+// are routed to Endpoint. Default: maxigobot.DefaultCallbackSeparator.
+func WithSeparator(sep string) Option {
+	return func(c *config) {
+		c.sep = sep
+	}
+}
+
+// WithSecret enables HMAC-SHA256 signing. A short signature is appended to
+// every payload and verified on decode, so users cannot forge callback data.
+func WithSecret(secret []byte) Option {
+	return func(c *config) {
+		c.secret = secret
+	}
+}
+
+// Codec encodes and decodes values of type T to callback payloads.
+type Codec[T any] struct {
+	unique string
+	sep    string
+	secret []byte
+	fields []int
+}
+
+// New creates a Codec for the struct type T with the given unique prefix.
+// Panics if T is not a struct, has unsupported field kinds, or if unique is
+// empty or contains the separator.
+func New[T any](unique string, opts ...Option) *Codec[T] {
+	cfg := config{sep: maxigobot.DefaultCallbackSeparator}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.sep == "" {
+		panic("callbackdata: separator must not be empty")
+	}
+	if unique == "" || strings.Contains(unique, cfg.sep) {
+		panic(fmt.Sprintf("callbackdata: invalid unique %q", unique))
+	}
+
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("callbackdata: %s is not a struct", t))
+	}
+	var fields []int
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() || f.Tag.Get("callbackdata") == "-" {
+			continue
+		}
+		if !supported(f.Type.Kind()) {
+			panic(fmt.Sprintf("callbackdata: field %s.%s has unsupported type %s", t, f.Name, f.Type))
+		}
+		fields = append(fields, i)
+	}
+
+	return &Codec[T]{unique: unique, sep: cfg.sep, secret: cfg.secret, fields: fields}
+}
+
+// Unique returns the codec's unique prefix.
+func (c *Codec[T]) Unique() string { return c.unique }
+
+// Endpoint returns the endpoint to register the codec's handler with.
+func (c *Codec[T]) Endpoint() string { return maxigobot.OnCallback(c.unique) }
+
+// Encode encodes v into a callback payload.
+// Returns ErrTooLong if the payload exceeds MaxPayloadLen.
+func (c *Codec[T]) Encode(v T) (string, error) {
+	rv := reflect.ValueOf(v)
+	parts := make([]string, 0, len(c.fields)+2)
+	parts = append(parts, c.unique)
+	for _, i := range c.fields {
+		parts = append(parts, c.escape(formatValue(rv.Field(i))))
+	}
+	payload := strings.Join(parts, c.sep)
+	if c.secret != nil {
+		payload += c.sep + c.sign(payload)
+	}
+	if len(payload) > MaxPayloadLen {
+		return "", fmt.Errorf("%w: %d bytes, max %d", ErrTooLong, len(payload), MaxPayloadLen)
+	}
+	return payload, nil
+}
+
+// Decode decodes a callback payload produced by Encode.
+func (c *Codec[T]) Decode(payload string) (T, error) {
+	var v T
+
+	if c.secret != nil {
+		// The signature has a fixed length and is cut from the end, since
+		// its alphabet may contain the separator.
+		n := len(payload) - signedLen - len(c.sep)
+		if n < 0 || payload[n:n+len(c.sep)] != c.sep {
+			return v, ErrMalformed
+		}
+		signed, sig := payload[:n], payload[n+len(c.sep):]
+		if !hmac.Equal([]byte(sig), []byte(c.sign(signed))) {
+			return v, ErrBadSignature
+		}
+		payload = signed
+	}
+
+	parts := strings.Split(payload, c.sep)
+	if len(parts) != len(c.fields)+1 || parts[0] != c.unique {
+		return v, ErrMalformed
+	}
+
+	rv := reflect.ValueOf(&v).Elem()
+	for n, i := range c.fields {
+		s, err := unescape(parts[n+1])
+		if err != nil {
+			return v, ErrMalformed
+		}
+		if err := parseValue(rv.Field(i), s); err != nil {
+			return v, fmt.Errorf("%w: field %s: %v", ErrMalformed, rv.Type().Field(i).Name, err)
+		}
+	}
+	return v, nil
+}
+
+// Button creates a callback button carrying v.
+// Returns an error if the payload cannot be encoded within MaxPayloadLen.
+func (c *Codec[T]) Button(text string, v T) (maxigo.Button, error) {
+	payload, err := c.Encode(v)
+	if err != nil {
+		return maxigo.Button{}, err
+	}
+	return maxigo.NewCallbackButton(text, payload), nil
+}
+
+// Handler adapts fn to a HandlerFunc that decodes the callback payload.
+// A payload that fails to decode or verify is reported as an error
+// without calling fn.
+func (c *Codec[T]) Handler(fn func(c maxigobot.Context, v T) error) maxigobot.HandlerFunc {
+	return func(ctx maxigobot.Context) error {
+		v, err := c.Decode(ctx.Data())
+		if err != nil {
+			return err
+		}
+		return fn(ctx, v)
+	}
+}
+
+// sign returns the truncated, base64url-encoded HMAC of payload.
+func (c *Codec[T]) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signatureLen])
+}
+
+// escape percent-encodes '%' and every byte of the separator in s.
+func (c *Codec[T]) escape(s string) string {
+	var sb strings.Builder
+	for i := range len(s) {
+		ch := s[i]
+		if ch == '%' || strings.IndexByte(c.sep, ch) >= 0 {
+			fmt.Fprintf(&sb, "%%%02X", ch)
+			continue
+		}
+		sb.WriteByte(ch)
+	}
+	return sb.String()
+}
+
+// unescape reverses escape.
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, "%") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", ErrMalformed
+		}
+		b, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", ErrMalformed
+		}
+		sb.WriteByte(byte(b))
+		i += 2
+	}
+	return sb.String(), nil
+}
+
+func supported(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// formatValue formats a field compactly: booleans as 1/0, numbers in base 10.
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		if v.Bool() {
+			return "1"
+		}
+		return "0"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	default: // Float32, Float64
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())
+	}
+}
+
+func parseValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		switch s {
+		case "1":
+			v.SetBool(true)
+		case "0":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid bool %q", s)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default: // Float32, Float64
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
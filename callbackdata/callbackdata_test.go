@@ -0,0 +1,188 @@
+package callbackdata
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+type item struct {
+	ID     int64
+	Color  string
+	Gift   bool
+	Price  float64
+	Count  uint8
+	hidden string
+	Skip   string `callbackdata:"-"`
+}
+
+// dataContext is a minimal Context stub returning a callback payload.
+type dataContext struct {
+	maxigobot.Context
+	data string
+}
+
+func (c *dataContext) Data() string { return c.data }
+
+func TestCodec_roundTrip(t *testing.T) {
+	codec := New[item]("buy")
+	in := item{ID: -42, Color: "blue:green%", Gift: true, Price: 9.5, Count: 3, hidden: "x", Skip: "y"}
+
+	payload, err := codec.Encode(in)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if want := "buy:-42:blue%3Agreen%25:1:9.5:3"; payload != want {
+		t.Errorf("payload = %q, want %q", payload, want)
+	}
+
+	out, err := codec.Decode(payload)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := in
+	want.hidden, want.Skip = "", ""
+	if out != want {
+		t.Errorf("Decode = %+v, want %+v", out, want)
+	}
+}
+
+func TestCodec_signed(t *testing.T) {
+	codec := New[item]("buy", WithSecret([]byte("secret")))
+	payload, err := codec.Encode(item{ID: 1})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if _, err := codec.Decode(payload); err != nil {
+		t.Fatalf("Decode signed payload: %v", err)
+	}
+
+	forged := strings.Replace(payload, "buy:1:", "buy:2:", 1)
+	if _, err := codec.Decode(forged); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Decode forged = %v, want ErrBadSignature", err)
+	}
+
+	other := New[item]("buy", WithSecret([]byte("other")))
+	if _, err := other.Decode(payload); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("Decode with other secret = %v, want ErrBadSignature", err)
+	}
+}
+
+func TestCodec_decodeMalformed(t *testing.T) {
+	codec := New[item]("buy")
+	for _, payload := range []string{
+		"",
+		"buy",
+		"sell:1:a:0:0:0",
+		"buy:1:a:0:0",
+		"buy:x:a:0:0:0",
+		"buy:1:a:2:0:0",
+		"buy:1:a%2:0:0:0",
+		"buy:1:a:0:0:300",
+	} {
+		if _, err := codec.Decode(payload); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Decode(%q) = %v, want ErrMalformed", payload, err)
+		}
+	}
+}
+
+func TestCodec_Button(t *testing.T) {
+	codec := New[item]("buy")
+	btn, err := codec.Button("Buy", item{ID: 7})
+	if err != nil {
+		t.Fatalf("Button: %v", err)
+	}
+	if btn.Type != "callback" || btn.Text != "Buy" || btn.Payload != "buy:7::0:0:0" {
+		t.Errorf("Button = %+v", btn)
+	}
+
+	_, err = codec.Button("Buy", item{Color: strings.Repeat("a", MaxPayloadLen)})
+	if !errors.Is(err, ErrTooLong) {
+		t.Errorf("Button with long payload = %v, want ErrTooLong", err)
+	}
+}
+
+func TestCodec_Handler(t *testing.T) {
+	codec := New[item]("buy")
+	if codec.Endpoint() != maxigobot.OnCallback("buy") {
+		t.Errorf("Endpoint() = %q", codec.Endpoint())
+	}
+
+	var got item
+	h := codec.Handler(func(c maxigobot.Context, v item) error {
+		got = v
+		return nil
+	})
+
+	if err := h(&dataContext{data: "buy:5:red:0:0:0"}); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if got.ID != 5 || got.Color != "red" {
+		t.Errorf("decoded = %+v", got)
+	}
+
+	if err := h(&dataContext{data: "buy:oops"}); !errors.Is(err, ErrMalformed) {
+		t.Errorf("handler with bad payload = %v, want ErrMalformed", err)
+	}
+}
+
+func TestNew_panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"empty unique", func() { New[item]("") }},
+		{"unique with separator", func() { New[item]("a:b") }},
+		{"empty separator", func() { New[item]("a", WithSeparator("")) }},
+		{"not a struct", func() { New[string]("a") }},
+		{"unsupported field", func() { New[struct{ S []string }]("a") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
+
+func TestWithSeparator(t *testing.T) {
+	codec := New[item]("buy", WithSeparator("|"))
+	payload, _ := codec.Encode(item{ID: 1, Color: "a|b:c"})
+	if payload != "buy|1|a%7Cb:c|0|0|0" {
+		t.Errorf("payload = %q", payload)
+	}
+	out, err := codec.Decode(payload)
+	if err != nil || out.Color != "a|b:c" {
+		t.Errorf("Decode = %+v, %v", out, err)
+	}
+}
+
+func TestWithSeparator_signed(t *testing.T) {
+	for _, sep := range []string{"_", "-", "|"} {
+		codec := New[item]("buy", WithSeparator(sep), WithSecret([]byte("secret")))
+		for id := range int64(200) {
+			in := item{ID: id, Color: "a" + sep + "b"}
+			payload, err := codec.Encode(in)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			out, err := codec.Decode(payload)
+			if err != nil || out != in {
+				t.Fatalf("sep %q: Decode(%q) = %+v, %v; want %+v", sep, payload, out, err, in)
+			}
+		}
+	}
+
+	codec := New[item]("buy", WithSeparator("_"), WithSecret([]byte("secret")))
+	for _, payload := range []string{"", "buy", "buy_1_a_0_0_0"} {
+		if _, err := codec.Decode(payload); !errors.Is(err, ErrMalformed) && !errors.Is(err, ErrBadSignature) {
+			t.Errorf("Decode(%q) = %v, want an error", payload, err)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package maxigobot
 import (
 	gocontext "context"
 	"errors"
+	"io"
 	"strings"
 	"sync"
 
@@ -25,8 +26,12 @@ type Context interface {
 	Update() maxigo.Update
 	// API returns the underlying maxigo-client for direct API calls.
 	API() *maxigo.Client
-	// Ctx returns the request-scoped context.Context for cancellation and deadlines.
+	// Ctx returns the request-scoped context.Context for cancellation and
+	// deadlines. It carries the UpdateInfo of the update being handled.
 	Ctx() gocontext.Context
+	// SetCtx replaces the request-scoped context, e.g. to add a deadline or
+	// values in middleware. It must not be called concurrently with Ctx.
+	SetCtx(ctx gocontext.Context)
 
 	// Sender returns the user who triggered the update (nil for some events).
 	Sender() *maxigo.User
@@ -43,22 +48,87 @@ type Context interface {
 	Payload() string
 	// Args returns the payload split by whitespace.
 	Args() []string
+	// Match returns the result of the pattern that routed this update:
+	// the full text followed by captured groups (nil if not routed by a pattern).
+	Match() []string
 
 	// Callback returns the callback object (nil if not a callback update).
 	Callback() *maxigo.Callback
 	// Data returns the callback payload string (empty if not a callback).
 	Data() string
+	// CallbackArgs returns the callback payload arguments after the unique
+	// prefix, e.g. ["42", "blue"] for "buy:42:blue" routed to OnCallback("buy").
+	// Nil if the callback matched its full payload.
+	CallbackArgs() []string
+
+	// Attachments returns the decoded attachments of the message: pointers to
+	// maxigo attachment types such as *maxigo.PhotoAttachment. Unknown and
+	// malformed attachments are skipped.
+	Attachments() []maxigo.Attachment
+	// Photos returns the photo attachments of the message.
+	Photos() []*maxigo.PhotoAttachment
+	// Files returns the file attachments of the message.
+	Files() []*maxigo.FileAttachment
+	// Location returns the first location attachment of the message (nil if none).
+	Location() *maxigo.LocationAttachment
+	// Contact returns the first contact attachment of the message (nil if none).
+	// verified reports whether the contact was shared with a request_contact
+	// button and its hash matches the bot token, proving the phone number
+	// belongs to the sender's Max account.
+	Contact() (contact *maxigo.ContactAttachment, verified bool)
+	// Download streams the file of a photo, video, audio, file or sticker
+	// attachment to w (see Bot.Download).
+	Download(att maxigo.Attachment, w io.Writer) error
 
 	// Send sends a message to the current chat.
 	Send(text string, opts ...SendOption) error
+	// SendMessage sends a message to the current chat and returns it,
+	// e.g. to edit or delete it later by its MID.
+	SendMessage(text string, opts ...SendOption) (*maxigo.Message, error)
 	// Reply sends a reply to the current message.
 	Reply(text string, opts ...SendOption) error
+	// ReplyMessage sends a reply to the current message and returns it.
+	ReplyMessage(text string, opts ...SendOption) (*maxigo.Message, error)
 	// Edit edits the current message.
 	Edit(text string, opts ...SendOption) error
+	// EditMessage edits the message with the given MID.
+	EditMessage(mid, text string, opts ...SendOption) error
 	// Delete deletes the current message.
 	Delete() error
+	// DeleteMessage deletes the message with the given MID.
+	DeleteMessage(mid string) error
 	// SendPhoto sends a photo to the current chat.
 	SendPhoto(photo *maxigo.PhotoAttachmentRequestPayload, opts ...SendOption) error
+	// SendPhotoMessage sends a photo to the current chat and returns the message.
+	SendPhotoMessage(photo *maxigo.PhotoAttachmentRequestPayload, opts ...SendOption) (*maxigo.Message, error)
+	// SendVideo uploads (if needed) and sends a video to the current chat.
+	SendVideo(f File, opts ...SendOption) error
+	// SendVideoMessage is like SendVideo but returns the sent message.
+	SendVideoMessage(f File, opts ...SendOption) (*maxigo.Message, error)
+	// SendAudio uploads (if needed) and sends an audio file to the current chat.
+	SendAudio(f File, opts ...SendOption) error
+	// SendAudioMessage is like SendAudio but returns the sent message.
+	SendAudioMessage(f File, opts ...SendOption) (*maxigo.Message, error)
+	// SendFile uploads (if needed) and sends a file to the current chat.
+	SendFile(f File, opts ...SendOption) error
+	// SendFileMessage is like SendFile but returns the sent message.
+	SendFileMessage(f File, opts ...SendOption) (*maxigo.Message, error)
+	// SendAlbum uploads (if needed) and sends several media in one message.
+	SendAlbum(media []Media, opts ...SendOption) error
+	// SendAlbumMessage is like SendAlbum but returns the sent message.
+	SendAlbumMessage(media []Media, opts ...SendOption) (*maxigo.Message, error)
+	// SendSticker sends a sticker by its code to the current chat.
+	SendSticker(code string, opts ...SendOption) error
+	// SendStickerMessage is like SendSticker but returns the sent message.
+	SendStickerMessage(code string, opts ...SendOption) (*maxigo.Message, error)
+	// SendLocation sends a geolocation to the current chat.
+	SendLocation(latitude, longitude float64, opts ...SendOption) error
+	// SendLocationMessage is like SendLocation but returns the sent message.
+	SendLocationMessage(latitude, longitude float64, opts ...SendOption) (*maxigo.Message, error)
+	// SendContact sends a contact card to the current chat.
+	SendContact(name, phone string, opts ...SendOption) error
+	// SendContactMessage is like SendContact but returns the sent message.
+	SendContactMessage(name, phone string, opts ...SendOption) (*maxigo.Message, error)
 
 	// Respond answers a callback with a notification.
 	Respond(text string) error
@@ -72,6 +142,13 @@ type Context interface {
 	Get(key string) any
 	// Set stores a value in the context store (thread-safe).
 	Set(key string, val any)
+
+	// State returns the conversation state of the sender in the current chat
+	// ("" if none or if no state storage is configured).
+	State() string
+	// SetState transitions the conversation to a new state. An empty state
+	// resets the conversation. Requires WithStateStorage.
+	SetState(state string) error
 }
 
 // updateMeta holds pre-extracted common fields from an update.
@@ -168,6 +245,16 @@ type nativeContext struct {
 	storeMu sync.RWMutex
 	command string
 	payload string
+	match   []string
+	// callbackArgs holds the payload arguments when a callback was routed by prefix.
+	callbackArgs []string
+
+	attOnce     sync.Once
+	attachments []maxigo.Attachment
+
+	stateMu     sync.Mutex
+	state       string
+	stateLoaded bool
 }
 
 func (c *nativeContext) Bot() *Bot             { return c.bot }
@@ -181,6 +268,8 @@ func (c *nativeContext) Ctx() gocontext.Context {
 	return gocontext.Background()
 }
 
+func (c *nativeContext) SetCtx(ctx gocontext.Context) { c.ctx = ctx }
+
 func (c *nativeContext) Sender() *maxigo.User    { return c.meta.sender }
 func (c *nativeContext) Chat() int64             { return c.meta.chatID }
 func (c *nativeContext) Message() *maxigo.Message { return c.meta.message }
@@ -213,6 +302,8 @@ func (c *nativeContext) Args() []string {
 	return strings.Fields(p)
 }
 
+func (c *nativeContext) Match() []string { return c.match }
+
 func (c *nativeContext) Callback() *maxigo.Callback {
 	if u, ok := c.update.(*maxigo.MessageCallbackUpdate); ok {
 		return &u.Callback
@@ -227,26 +318,48 @@ func (c *nativeContext) Data() string {
 	return ""
 }
 
+func (c *nativeContext) CallbackArgs() []string { return c.callbackArgs }
+
 func (c *nativeContext) Send(text string, opts ...SendOption) error {
+	_, err := c.SendMessage(text, opts...)
+	return err
+}
+
+func (c *nativeContext) SendMessage(text string, opts ...SendOption) (*maxigo.Message, error) {
 	chatID := c.Chat()
 	if chatID == 0 {
-		return &BotError{Err: ErrNoChatID}
+		return nil, &BotError{Err: ErrNoChatID}
 	}
 	cfg := buildSendConfig(opts)
+	if err := c.bot.uploadMedia(c.Ctx(), c.Chat(), &cfg); err != nil {
+		return nil, err
+	}
 	body := toMessageBody(text, cfg)
-	return withRetry(c.Ctx(), c.bot.retry, func() error {
-		_, err := c.bot.client.SendMessage(c.Ctx(), chatID, body)
+	var msg *maxigo.Message
+	err := c.bot.call(c.Ctx(), chatID, func() error {
+		var err error
+		msg, err = c.bot.client.SendMessage(c.Ctx(), chatID, body)
 		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+	c.scheduleDelete(chatID, msg.Body.MID, cfg)
+	return msg, nil
 }
 
 func (c *nativeContext) Reply(text string, opts ...SendOption) error {
+	_, err := c.ReplyMessage(text, opts...)
+	return err
+}
+
+func (c *nativeContext) ReplyMessage(text string, opts ...SendOption) (*maxigo.Message, error) {
 	msg := c.Message()
 	if msg == nil {
-		return c.Send(text, opts...)
+		return c.SendMessage(text, opts...)
 	}
 	opts = append([]SendOption{WithReplyTo(msg.Body.MID)}, opts...)
-	return c.Send(text, opts...)
+	return c.SendMessage(text, opts...)
 }
 
 func (c *nativeContext) Edit(text string, opts ...SendOption) error {
@@ -254,10 +367,17 @@ func (c *nativeContext) Edit(text string, opts ...SendOption) error {
 	if msg == nil {
 		return &BotError{Err: ErrNoMessage}
 	}
+	return c.EditMessage(msg.Body.MID, text, opts...)
+}
+
+func (c *nativeContext) EditMessage(mid, text string, opts ...SendOption) error {
 	cfg := buildSendConfig(opts)
+	if err := c.bot.uploadMedia(c.Ctx(), c.Chat(), &cfg); err != nil {
+		return err
+	}
 	body := toMessageBody(text, cfg)
-	return withRetry(c.Ctx(), c.bot.retry, func() error {
-		_, err := c.bot.client.EditMessage(c.Ctx(), msg.Body.MID, body)
+	return c.bot.call(c.Ctx(), c.Chat(), func() error {
+		_, err := c.bot.client.EditMessage(c.Ctx(), mid, body)
 		return err
 	})
 }
@@ -267,27 +387,46 @@ func (c *nativeContext) Delete() error {
 	if msg == nil {
 		return &BotError{Err: ErrNoMessage}
 	}
-	return withRetry(c.Ctx(), c.bot.retry, func() error {
-		_, err := c.bot.client.DeleteMessage(c.Ctx(), msg.Body.MID)
+	return c.DeleteMessage(msg.Body.MID)
+}
+
+func (c *nativeContext) DeleteMessage(mid string) error {
+	return c.bot.call(c.Ctx(), c.Chat(), func() error {
+		_, err := c.bot.client.DeleteMessage(c.Ctx(), mid)
 		return err
 	})
 }
 
 func (c *nativeContext) SendPhoto(photo *maxigo.PhotoAttachmentRequestPayload, opts ...SendOption) error {
+	_, err := c.SendPhotoMessage(photo, opts...)
+	return err
+}
+
+func (c *nativeContext) SendPhotoMessage(photo *maxigo.PhotoAttachmentRequestPayload, opts ...SendOption) (*maxigo.Message, error) {
 	if photo == nil {
-		return &BotError{Err: ErrNilPhoto}
+		return nil, &BotError{Err: ErrNilPhoto}
 	}
 	chatID := c.Chat()
 	if chatID == 0 {
-		return &BotError{Err: ErrNoChatID}
+		return nil, &BotError{Err: ErrNoChatID}
 	}
 	cfg := buildSendConfig(opts)
+	if err := c.bot.uploadMedia(c.Ctx(), chatID, &cfg); err != nil {
+		return nil, err
+	}
 	cfg.Attachments = append(cfg.Attachments, maxigo.NewPhotoAttachment(*photo))
 	body := toMessageBody("", cfg)
-	return withRetry(c.Ctx(), c.bot.retry, func() error {
-		_, err := c.bot.client.SendMessage(c.Ctx(), chatID, body)
+	var msg *maxigo.Message
+	err := c.bot.call(c.Ctx(), chatID, func() error {
+		var err error
+		msg, err = c.bot.client.SendMessage(c.Ctx(), chatID, body)
 		return err
 	})
+	if err != nil {
+		return nil, err
+	}
+	c.scheduleDelete(chatID, msg.Body.MID, cfg)
+	return msg, nil
 }
 
 func (c *nativeContext) Respond(text string) error {
@@ -295,7 +434,7 @@ func (c *nativeContext) Respond(text string) error {
 	if cb == nil {
 		return &BotError{Err: ErrNoCallback}
 	}
-	return withRetry(c.Ctx(), c.bot.retry, func() error {
+	return c.bot.call(c.Ctx(), c.Chat(), func() error {
 		_, err := c.bot.client.AnswerCallback(c.Ctx(), cb.CallbackID, &maxigo.CallbackAnswer{
 			Notification: maxigo.Some(text),
 		})
@@ -313,7 +452,7 @@ func (c *nativeContext) Notify(action maxigo.SenderAction) error {
 	if chatID == 0 {
 		return &BotError{Err: ErrNoChatID}
 	}
-	return withRetry(c.Ctx(), c.bot.retry, func() error {
+	return c.bot.call(c.Ctx(), chatID, func() error {
 		_, err := c.bot.client.SendAction(c.Ctx(), chatID, action)
 		return err
 	})
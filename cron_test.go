@@ -0,0 +1,68 @@
+package maxigobot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCron_invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every",
+	}
+	for _, spec := range tests {
+		if _, err := parseCron(spec); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("parseCron(%q) err = %v, want ErrInvalidCron", spec, err)
+		}
+	}
+}
+
+func TestCronSchedule_next(t *testing.T) {
+	// Wednesday, 2026-01-14 10:30:45 UTC.
+	from := time.Date(2026, 1, 14, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 1, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 1, 14, 10, 45, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, 1, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 9-18/3 * * *", time.Date(2026, 1, 14, 12, 0, 0, 0, time.UTC)},
+		{"30 8 * * 1-5", time.Date(2026, 1, 15, 8, 30, 0, 0, time.UTC)},
+		{"0 12 * * 0", time.Date(2026, 1, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2026, 1, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0,30 10,11 * * *", time.Date(2026, 1, 14, 11, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (the 20th or a Friday).
+		{"0 0 20 * 5", time.Date(2026, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 1, 14, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2026, 1, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := parseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("parseCron: %v", err)
+			}
+			if got := s.next(from); !got.Equal(tt.want) {
+				t.Errorf("next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package maxigobot
+
+const (
+	// defaultQueueSize is the default capacity of the channel between the poller and the dispatcher.
+	defaultQueueSize = 100
+	// defaultOrderedWorkers is the number of workers used by ordered dispatch
+	// when WithWorkers is not set.
+	defaultOrderedWorkers = 32
+	// workerQueueSize is the capacity of each worker's queue in ordered dispatch.
+	workerQueueSize = 16
+)
+
+// Ordering controls which updates are guaranteed to be handled sequentially.
+type Ordering int
+
+const (
+	// OrderNone handles updates concurrently without ordering guarantees (default).
+	OrderNone Ordering = iota
+	// OrderByChat handles updates from the same chat one at a time, in the
+	// order they were received. Different chats are processed in parallel.
+	OrderByChat
+	// OrderBySender handles updates from the same user one at a time, in the
+	// order they were received. Different users are processed in parallel.
+	OrderBySender
+)
+
+// Stats is a point-in-time snapshot of the update dispatcher.
+type Stats struct {
+	// Queued is the number of updates received from the poller that are
+	// waiting for a handler.
+	Queued int
+	// InFlight is the number of updates currently being processed.
+	InFlight int
+	// Workers is the size of the worker pool (0 means one goroutine per update).
+	Workers int
+	// Throttled is the number of outgoing API calls waiting for the rate
+	// limiter (see WithRateLimit).
+	Throttled int
+}
+
+// Stats returns a snapshot of the dispatcher state. It is safe to call
+// concurrently with Start, e.g. from a metrics exporter.
+func (b *Bot) Stats() Stats {
+	s := Stats{
+		InFlight: int(b.inFlight.Load()),
+		Workers:  b.workers,
+	}
+	if b.limit != nil {
+		s.Throttled = int(b.limit.waiting.Load())
+	}
+	if q := b.queue.Load(); q != nil {
+		s.Queued = len(*q)
+	}
+	s.Queued += int(b.partitioned.Load())
+	if s.Workers <= 0 && b.ordering != OrderNone {
+		s.Workers = defaultOrderedWorkers
+	}
+	return s
+}
+
+// dispatch reads updates until the channel is closed and runs them
+// through processUpdate. With a worker pool, a fixed number of goroutines
+// consume the channel, so a slow handler applies backpressure to the poller
+// once the queue is full. Without one, every update gets its own goroutine.
+func (b *Bot) dispatch(updates <-chan any) {
+	if b.ordering != OrderNone {
+		b.dispatchOrdered(updates)
+		return
+	}
+
+	if b.workers <= 0 {
+		for upd := range updates {
+			b.wg.Add(1)
+			go func(u any) {
+				defer b.wg.Done()
+				b.runUpdate(u)
+			}(upd)
+		}
+		return
+	}
+
+	for i := 0; i < b.workers; i++ {
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			for upd := range updates {
+				b.runUpdate(upd)
+			}
+		}()
+	}
+}
+
+// runUpdate processes a single update and tracks it in Stats.
+func (b *Bot) runUpdate(update any) {
+	b.inFlight.Add(1)
+	defer b.inFlight.Add(-1)
+	b.processUpdate(update)
+}
+
+// dispatchOrdered partitions updates between workers by their ordering key,
+// so that updates sharing a key always land on the same worker and are
+// handled sequentially. Updates without a key (e.g. no chat or sender) are
+// spread across workers round-robin.
+//
+// A slow handler delays other keys that hash to the same worker; increase
+// the number of workers with WithWorkers to reduce such collisions.
+func (b *Bot) dispatchOrdered(updates <-chan any) {
+	n := b.workers
+	if n <= 0 {
+		n = defaultOrderedWorkers
+	}
+
+	queues := make([]chan any, n)
+	for i := range queues {
+		queues[i] = make(chan any, workerQueueSize)
+		b.wg.Add(1)
+		go func(q <-chan any) {
+			defer b.wg.Done()
+			for upd := range q {
+				b.partitioned.Add(-1)
+				b.runUpdate(upd)
+			}
+		}(queues[i])
+	}
+
+	var next uint64
+	for upd := range updates {
+		var idx uint64
+		if key, ok := b.orderKey(upd); ok {
+			idx = uint64(key) % uint64(n)
+		} else {
+			idx = next % uint64(n)
+			next++
+		}
+		b.partitioned.Add(1)
+		queues[idx] <- upd
+	}
+
+	for _, q := range queues {
+		close(q)
+	}
+}
+
+// orderKey returns the partitioning key of an update for the configured ordering.
+func (b *Bot) orderKey(update any) (int64, bool) {
+	meta := extractMeta(update)
+	switch b.ordering {
+	case OrderByChat:
+		return meta.chatID, meta.chatID != 0
+	case OrderBySender:
+		if meta.sender != nil {
+			return meta.sender.UserID, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,212 @@
+package maxigobot
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+func TestWithWorkers(t *testing.T) {
+	b, err := New("token", WithWorkers(4), WithQueueSize(10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.workers != 4 {
+		t.Errorf("workers = %d, want 4", b.workers)
+	}
+	if b.queueSize != 10 {
+		t.Errorf("queueSize = %d, want 10", b.queueSize)
+	}
+}
+
+func TestDispatch_workerPoolLimitsConcurrency(t *testing.T) {
+	b, _ := New("token", WithWorkers(2))
+
+	const total = 10
+	var updates []any
+	for i := 0; i < total; i++ {
+		updates = append(updates, &maxigo.BotStartedUpdate{ChatID: int64(i)})
+	}
+	b.poller = &mockPoller{updates: updates}
+
+	var (
+		cur, peak atomic.Int32
+		done      sync.WaitGroup
+	)
+	done.Add(total)
+	b.Handle(OnBotStarted, func(c Context) error {
+		defer done.Done()
+		n := cur.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		cur.Add(-1)
+		return nil
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		b.Start()
+		close(finished)
+	}()
+
+	done.Wait()
+	b.Stop()
+
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start() did not return after Stop()")
+	}
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", got)
+	}
+}
+
+func TestBot_Stats(t *testing.T) {
+	b, _ := New("token", WithWorkers(1), WithQueueSize(5))
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	b.Handle(OnBotStarted, func(c Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+		return nil
+	})
+	b.poller = &mockPoller{updates: []any{
+		&maxigo.BotStartedUpdate{},
+		&maxigo.BotStartedUpdate{},
+		&maxigo.BotStartedUpdate{},
+	}}
+
+	if s := b.Stats(); s.Queued != 0 || s.InFlight != 0 {
+		t.Errorf("Stats before Start = %+v, want zero queue", s)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		b.Start()
+		close(finished)
+	}()
+
+	<-started
+	deadline := time.Now().Add(time.Second)
+	for b.Stats().Queued != 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	s := b.Stats()
+	if s.InFlight != 1 {
+		t.Errorf("InFlight = %d, want 1", s.InFlight)
+	}
+	if s.Queued != 2 {
+		t.Errorf("Queued = %d, want 2", s.Queued)
+	}
+	if s.Workers != 1 {
+		t.Errorf("Workers = %d, want 1", s.Workers)
+	}
+
+	close(release)
+	b.Stop()
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start() did not return after Stop()")
+	}
+}
+
+func TestDispatch_orderByChat(t *testing.T) {
+	b, _ := New("token", WithOrdering(OrderByChat), WithWorkers(4))
+
+	const perChat = 5
+	var updates []any
+	for i := 0; i < perChat; i++ {
+		for chat := int64(1); chat <= 3; chat++ {
+			updates = append(updates, &maxigo.BotStartedUpdate{ChatID: chat, Payload: ptr(string(rune('a' + i)))})
+		}
+	}
+	b.poller = &mockPoller{updates: updates}
+
+	var (
+		mu     sync.Mutex
+		got    = make(map[int64]string)
+		active = make(map[int64]bool)
+		done   sync.WaitGroup
+	)
+	done.Add(len(updates))
+	b.Handle(OnBotStarted, func(c Context) error {
+		defer done.Done()
+		mu.Lock()
+		if active[c.Chat()] {
+			t.Errorf("chat %d handled concurrently", c.Chat())
+		}
+		active[c.Chat()] = true
+		mu.Unlock()
+
+		// Earlier updates sleep longer, so unordered dispatch would reorder them.
+		p := c.Payload()
+		time.Sleep(time.Duration(perChat-int(p[0]-'a')) * 2 * time.Millisecond)
+
+		mu.Lock()
+		got[c.Chat()] += p
+		active[c.Chat()] = false
+		mu.Unlock()
+		return nil
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		b.Start()
+		close(finished)
+	}()
+
+	done.Wait()
+	b.Stop()
+	<-finished
+
+	for chat := int64(1); chat <= 3; chat++ {
+		if got[chat] != "abcde" {
+			t.Errorf("chat %d order = %q, want %q", chat, got[chat], "abcde")
+		}
+	}
+}
+
+func TestOrderKey(t *testing.T) {
+	user := maxigo.User{UserID: 7}
+	upd := &maxigo.BotStartedUpdate{ChatID: -100, User: user}
+
+	tests := []struct {
+		name     string
+		ordering Ordering
+		update   any
+		wantKey  int64
+		wantOK   bool
+	}{
+		{"by chat", OrderByChat, upd, -100, true},
+		{"by sender", OrderBySender, upd, 7, true},
+		{"by chat without chat", OrderByChat, &maxigo.MessageChatCreatedUpdate{}, 0, false},
+		{"by sender without sender", OrderBySender, &maxigo.MessageRemovedUpdate{ChatID: 1}, 0, false},
+		{"none", OrderNone, upd, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bot{ordering: tt.ordering}
+			key, ok := b.orderKey(tt.update)
+			if key != tt.wantKey || ok != tt.wantOK {
+				t.Errorf("orderKey = (%d, %v), want (%d, %v)", key, ok, tt.wantKey, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/maxigo-bot/maxigo-bot/internal/fileutil"
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// DefaultDownloadLimit is the default maximum size of a downloaded file (50 MB),
+// the same limit maxigo-client applies when fetching URLs for upload.
+const DefaultDownloadLimit = 50 << 20
+
+// DefaultDownloadTimeout is the request timeout of the default download
+// client (see WithDownloadClient).
+const DefaultDownloadTimeout = 5 * time.Minute
+
+// defaultDownloadClient is used by Download unless WithDownloadClient is set.
+var defaultDownloadClient = &http.Client{Timeout: DefaultDownloadTimeout}
+
+var (
+	// ErrDownloadTooLarge is returned when a download exceeds the size limit
+	// (see WithDownloadLimit).
+	ErrDownloadTooLarge = errors.New("maxigobot: download exceeds size limit")
+	// ErrNoDownloadURL is returned by Context.Download for attachments
+	// without a file URL (e.g. contacts and locations).
+	ErrNoDownloadURL = errors.New("maxigobot: attachment has no download URL")
+)
+
+// WithDownloadLimit sets the maximum size in bytes of files fetched with
+// Download, DownloadFile and Context.Download.
+// Default: DefaultDownloadLimit. Pass a negative value to disable the limit.
+func WithDownloadLimit(n int64) Option {
+	return func(b *Bot) {
+		b.downloadLimit = n
+	}
+}
+
+// WithDownloadClient sets the HTTP client used by Download, DownloadFile and
+// Context.Download, e.g. one with a custom CA (see maxigo.WithRussianTrustedCA)
+// or proxy. Set a timeout on the client to bound stalled downloads.
+// Default: a client with DefaultDownloadTimeout.
+func WithDownloadClient(c *http.Client) Option {
+	return func(b *Bot) {
+		b.downloader = c
+	}
+}
+
+// Download streams the file at rawURL to w. Only http and https URLs are
+// allowed, and at most the download limit is read (see WithDownloadLimit).
+// Fetch failures are returned as *maxigo.Error with Kind maxigo.ErrFetch.
+//
+// Security: do not pass untrusted user input as rawURL without validation,
+// as it allows requests to internal networks. URLs of incoming attachments
+// are issued by Max.
+func (b *Bot) Download(ctx gocontext.Context, rawURL string, w io.Writer) error {
+	const op = "Download"
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &maxigo.Error{Kind: maxigo.ErrFetch, Op: op, Message: "parse URL: " + err.Error(), Err: err}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &maxigo.Error{Kind: maxigo.ErrFetch, Op: op,
+			Message: fmt.Sprintf("unsupported URL scheme %q: only http and https are allowed", u.Scheme)}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return &maxigo.Error{Kind: maxigo.ErrFetch, Op: op, Message: err.Error(), Err: err}
+	}
+	client := b.downloader
+	if client == nil {
+		client = defaultDownloadClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &maxigo.Error{Kind: maxigo.ErrFetch, Op: op, Message: err.Error(), Err: err}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return &maxigo.Error{Kind: maxigo.ErrFetch, Op: op, StatusCode: resp.StatusCode,
+			Message: fmt.Sprintf("fetch %s: %s", u.Redacted(), http.StatusText(resp.StatusCode))}
+	}
+
+	limit := b.downloadLimit
+	if limit == 0 {
+		limit = DefaultDownloadLimit
+	}
+	if limit < 0 {
+		_, err = io.Copy(w, resp.Body)
+		return err
+	}
+	if resp.ContentLength > limit {
+		return fmt.Errorf("%w: %d bytes, max %d", ErrDownloadTooLarge, resp.ContentLength, limit)
+	}
+	n, err := io.Copy(w, io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return err
+	}
+	if n > limit {
+		return fmt.Errorf("%w: max %d bytes", ErrDownloadTooLarge, limit)
+	}
+	return nil
+}
+
+// DownloadFile downloads the file at rawURL to path (see Download).
+// The content is streamed to a temporary file that replaces path only
+// after a complete download, so a failed or canceled download leaves
+// no partial file behind.
+func (b *Bot) DownloadFile(ctx gocontext.Context, rawURL, path string) error {
+	return fileutil.WriteAtomicFunc(path, func(w io.Writer) error {
+		return b.Download(ctx, rawURL, w)
+	})
+}
+
+func (c *nativeContext) Download(att maxigo.Attachment, w io.Writer) error {
+	u := attachmentURL(att)
+	if u == "" {
+		return &BotError{Err: ErrNoDownloadURL}
+	}
+	return c.bot.Download(c.Ctx(), u, w)
+}
+
+// attachmentURL returns the file URL of an incoming attachment ("" if none).
+func attachmentURL(att maxigo.Attachment) string {
+	switch a := att.(type) {
+	case *maxigo.PhotoAttachment:
+		return a.Payload.URL
+	case *maxigo.VideoAttachment:
+		return a.Payload.URL
+	case *maxigo.AudioAttachment:
+		return a.Payload.URL
+	case *maxigo.FileAttachment:
+		return a.Payload.URL
+	case *maxigo.StickerAttachment:
+		return a.Payload.URL
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package maxigobot
+
+import (
+	"bytes"
+	gocontext "context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+func downloadServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/photo.jpg", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("jpeg-bytes"))
+	})
+	mux.HandleFunc("/big", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte("x"), 100))
+	})
+	mux.HandleFunc("/chunked", func(w http.ResponseWriter, r *http.Request) {
+		for range 10 {
+			_, _ = w.Write(bytes.Repeat([]byte("x"), 10))
+			w.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBot_Download(t *testing.T) {
+	srv := downloadServer(t)
+	b := newTestBot()
+
+	var buf bytes.Buffer
+	if err := b.Download(gocontext.Background(), srv.URL+"/photo.jpg", &buf); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if buf.String() != "jpeg-bytes" {
+		t.Errorf("content = %q", buf.String())
+	}
+}
+
+func TestWithDownloadClient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tls-bytes"))
+	}))
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+	if err := newTestBot().Download(gocontext.Background(), srv.URL, &buf); err == nil {
+		t.Fatal("default client should not trust the test certificate")
+	}
+
+	b, _ := New("token", WithDownloadClient(srv.Client()))
+	if err := b.Download(gocontext.Background(), srv.URL, &buf); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if buf.String() != "tls-bytes" {
+		t.Errorf("content = %q", buf.String())
+	}
+}
+
+func TestDefaultDownloadClient_timeout(t *testing.T) {
+	if defaultDownloadClient.Timeout != DefaultDownloadTimeout {
+		t.Errorf("Timeout = %v, want %v", defaultDownloadClient.Timeout, DefaultDownloadTimeout)
+	}
+}
+
+func TestBot_Download_errors(t *testing.T) {
+	srv := downloadServer(t)
+	b := newTestBot()
+
+	tests := []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{"file scheme", "file:///etc/passwd", 0},
+		{"ftp scheme", "ftp://example.com/file", 0},
+		{"not found", srv.URL + "/missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.Download(gocontext.Background(), tt.url, &bytes.Buffer{})
+			var e *maxigo.Error
+			if !errors.As(err, &e) || e.Kind != maxigo.ErrFetch {
+				t.Fatalf("err = %v, want fetch error", err)
+			}
+			if e.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", e.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestBot_Download_limit(t *testing.T) {
+	srv := downloadServer(t)
+
+	tests := []struct {
+		name    string
+		limit   int64
+		path    string
+		wantErr bool
+	}{
+		{"content length over limit", 50, "/big", true},
+		{"streamed over limit", 50, "/chunked", true},
+		{"exactly at limit", 100, "/chunked", false},
+		{"unlimited", -1, "/big", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot()
+			WithDownloadLimit(tt.limit)(b)
+
+			err := b.Download(gocontext.Background(), srv.URL+tt.path, &bytes.Buffer{})
+			if tt.wantErr != errors.Is(err, ErrDownloadTooLarge) {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBot_Download_canceled(t *testing.T) {
+	srv := downloadServer(t)
+	b := newTestBot()
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	if err := b.Download(ctx, srv.URL+"/photo.jpg", &bytes.Buffer{}); !errors.Is(err, gocontext.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestBot_DownloadFile(t *testing.T) {
+	srv := downloadServer(t)
+	b := newTestBot()
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "photo.jpg")
+	if err := b.DownloadFile(gocontext.Background(), srv.URL+"/photo.jpg", path); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "jpeg-bytes" {
+		t.Errorf("file = %q, %v", data, err)
+	}
+
+	// A failed download leaves neither the target nor a temporary file.
+	failed := filepath.Join(dir, "missing")
+	if err := b.DownloadFile(gocontext.Background(), srv.URL+"/missing", failed); err == nil {
+		t.Fatal("expected error")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want only photo.jpg", len(entries))
+	}
+}
+
+func TestNativeContext_Download(t *testing.T) {
+	srv := downloadServer(t)
+	ctx := newTestContext(newTestBot(), attachmentUpdate(
+		`{"type":"image","payload":{"url":"`+srv.URL+`/photo.jpg"}}`,
+		`{"type":"location","latitude":1,"longitude":2}`,
+	))
+	atts := ctx.Attachments()
+
+	var buf bytes.Buffer
+	if err := ctx.Download(atts[0], &buf); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if buf.String() != "jpeg-bytes" {
+		t.Errorf("content = %q", buf.String())
+	}
+
+	if err := ctx.Download(atts[1], &buf); !errors.Is(err, ErrNoDownloadURL) {
+		t.Errorf("location: err = %v, want ErrNoDownloadURL", err)
+	}
+}
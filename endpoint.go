@@ -14,7 +14,9 @@ const (
 
 // Attachment-based event endpoints.
 // These take priority over OnText when the message contains a matching attachment.
-// If no handler is registered for the attachment event, routing falls back to OnText → OnMessage.
+// If no handler is registered for the first attachment's event, routing tries
+// the events of the other attachments, then OnMedia (for media attachments),
+// then falls back to OnText → OnMessage.
 const (
 	// OnContact matches message_created updates with a contact attachment.
 	OnContact = "\acontact"
@@ -22,6 +24,19 @@ const (
 	OnPhoto = "\aphoto"
 	// OnLocation matches message_created updates with a location attachment.
 	OnLocation = "\alocation"
+	// OnVideo matches message_created updates with a video attachment.
+	OnVideo = "\avideo"
+	// OnAudio matches message_created updates with an audio attachment.
+	OnAudio = "\aaudio"
+	// OnFile matches message_created updates with a file attachment.
+	OnFile = "\afile"
+	// OnSticker matches message_created updates with a sticker attachment.
+	OnSticker = "\asticker"
+	// OnShare matches message_created updates with a shared link attachment.
+	OnShare = "\ashare"
+	// OnMedia matches message_created updates with any photo, video, audio
+	// or file attachment that has no more specific handler.
+	OnMedia = "\amedia"
 )
 
 // Lifecycle hook endpoints.
@@ -0,0 +1,90 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"time"
+)
+
+const (
+	// deleteJobName is the name of the built-in job that deletes a message.
+	deleteJobName = "maxigobot.delete"
+	// deleteFlushTimeout bounds how long Start spends deleting pending
+	// messages on shutdown (see DeleteAfter).
+	deleteFlushTimeout = 10 * time.Second
+)
+
+// WithDeleteAfter deletes the sent message after d, e.g. for service
+// messages like "Processing…" or validation errors:
+//
+//	c.Send("Invalid date", maxigobot.WithDeleteAfter(10*time.Second))
+//
+// See Bot.DeleteAfter for how pending deletions are handled on Stop.
+func WithDeleteAfter(d time.Duration) SendOption {
+	return func(cfg *SendConfig) {
+		cfg.DeleteAfter = d
+	}
+}
+
+// DeleteAfter schedules deletion of the message mid in chatID after d.
+// Deletions are scheduled jobs (see ScheduleAt): with a persistent
+// JobStore (WithJobStore) pending deletions survive restarts; with a
+// MemoryJobStore (the default) they are performed right away when the bot
+// stops, so no message is left behind, unless a Shutdown deadline expires.
+func (b *Bot) DeleteAfter(chatID int64, mid string, d time.Duration) error {
+	_, err := b.ScheduleAfter(d, Job{
+		ID:      deleteJobName + ":" + mid,
+		Name:    deleteJobName,
+		ChatID:  chatID,
+		Payload: mid,
+	})
+	return err
+}
+
+// scheduleDelete schedules deletion of a sent message if cfg asks for it.
+// The message was sent, so a scheduling failure is reported to OnError
+// instead of being returned.
+func (c *nativeContext) scheduleDelete(chatID int64, mid string, cfg SendConfig) {
+	if cfg.DeleteAfter <= 0 || mid == "" {
+		return
+	}
+	if err := c.bot.DeleteAfter(chatID, mid, cfg.DeleteAfter); err != nil {
+		c.bot.handleError(err, c, "delete")
+	}
+}
+
+// runDeleteJob is the handler of the built-in delete job.
+func runDeleteJob(c Context) error {
+	return c.DeleteMessage(c.Payload())
+}
+
+// flushDeletes performs the pending deletions of an in-memory job store,
+// which would otherwise be lost on shutdown. Called after all handlers and
+// jobs have finished.
+func (b *Bot) flushDeletes() {
+	s := b.scheduler()
+	if _, ok := s.store.(*MemoryJobStore); !ok {
+		return // Persisted; deleted after restart.
+	}
+	s.mu.Lock()
+	var pending []Job
+	for id, j := range s.jobs {
+		if j.Name == deleteJobName {
+			pending = append(pending, j)
+			delete(s.jobs, id)
+		}
+	}
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), deleteFlushTimeout)
+	defer cancel()
+	for _, j := range pending {
+		c := &nativeContext{bot: b, meta: updateMeta{chatID: j.ChatID}, payload: j.Payload, ctx: ctx}
+		if err := runDeleteJob(c); err != nil {
+			b.handleError(err, c, "job:"+j.Name)
+		}
+		_ = s.store.DeleteJob(ctx, j.ID)
+	}
+}
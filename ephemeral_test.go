@@ -0,0 +1,120 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// deleteServer is a fake API that sends messages with sequential MIDs and
+// records deleted MIDs.
+type deleteServer struct {
+	mu      sync.Mutex
+	deleted []string
+	done    chan struct{}
+}
+
+func newDeleteBot(t *testing.T, opts ...Option) (*Bot, *deleteServer) {
+	t.Helper()
+	ds := &deleteServer{done: make(chan struct{}, 10)}
+	b := testBotWithServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			writeJSON(t, w, `{"message":{"recipient":{"chat_id":100,"chat_type":"dialog"},"timestamp":1,"body":{"mid":"sent1","seq":1}}}`)
+		case http.MethodDelete:
+			ds.mu.Lock()
+			ds.deleted = append(ds.deleted, r.URL.Query().Get("message_id"))
+			ds.mu.Unlock()
+			writeJSON(t, w, `{"success":true}`)
+			ds.done <- struct{}{}
+		}
+	})
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b, ds
+}
+
+func (ds *deleteServer) deletedMIDs() []string {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return append([]string(nil), ds.deleted...)
+}
+
+func TestWithDeleteAfter(t *testing.T) {
+	b, ds := newDeleteBot(t)
+	stop := startBot(t, b)
+	defer stop()
+
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+	if err := ctx.Send("Processing…", WithDeleteAfter(20*time.Millisecond)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if got := ds.deletedMIDs(); len(got) != 0 {
+		t.Fatalf("deleted too early: %v", got)
+	}
+
+	waitFor(t, ds.done)
+	if got := ds.deletedMIDs(); len(got) != 1 || got[0] != "sent1" {
+		t.Errorf("deleted = %v, want [sent1]", got)
+	}
+}
+
+func TestWithDeleteAfter_flushedOnStop(t *testing.T) {
+	b, ds := newDeleteBot(t)
+	stop := startBot(t, b)
+
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+	photo := &maxigo.PhotoAttachmentRequestPayload{Token: maxigo.Some("tok")}
+	if err := ctx.SendPhoto(photo, WithDeleteAfter(time.Hour)); err != nil {
+		t.Fatalf("SendPhoto: %v", err)
+	}
+	stop()
+
+	if got := ds.deletedMIDs(); len(got) != 1 || got[0] != "sent1" {
+		t.Errorf("deleted = %v, want pending deletion flushed on stop", got)
+	}
+}
+
+func TestWithDeleteAfter_flushedOnStop_explicitMemoryStore(t *testing.T) {
+	b, ds := newDeleteBot(t, WithJobStore(NewMemoryJobStore()))
+	stop := startBot(t, b)
+
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+	if err := ctx.Send("bye", WithDeleteAfter(time.Hour)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	stop()
+
+	if got := ds.deletedMIDs(); len(got) != 1 || got[0] != "sent1" {
+		t.Errorf("deleted = %v, want pending deletion flushed on stop", got)
+	}
+}
+
+func TestWithDeleteAfter_persistedOnStop(t *testing.T) {
+	store, err := NewFileJobStore(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, ds := newDeleteBot(t, WithJobStore(store))
+	stop := startBot(t, b)
+
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+	if _, err := ctx.SendMessage("bye", WithDeleteAfter(time.Hour)); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	stop()
+
+	if got := ds.deletedMIDs(); len(got) != 0 {
+		t.Errorf("deleted = %v, want none", got)
+	}
+	jobs, _ := store.LoadJobs(gocontext.Background())
+	if len(jobs) != 1 || jobs[0].Name != deleteJobName || jobs[0].Payload != "sent1" || jobs[0].ChatID != 100 {
+		t.Errorf("stored jobs = %+v", jobs)
+	}
+}
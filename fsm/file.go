@@ -0,0 +1,123 @@
+package fsm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+	"github.com/maxigo-bot/maxigo-bot/internal/fileutil"
+)
+
+// FileStorage is a StateStorage that keeps all states in memory and persists
+// them to a JSON file on every change, so conversations survive restarts.
+// Every transition rewrites the file, so it suits bots with a moderate number
+// of active conversations.
+type FileStorage struct {
+	path string
+
+	mu     sync.RWMutex
+	states map[maxigobot.StateKey]string
+}
+
+// NewFileStorage opens the state file at path, creating it on first write.
+func NewFileStorage(path string) (*FileStorage, error) {
+	s := &FileStorage{
+		path:   path,
+		states: make(map[maxigobot.StateKey]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fsm: read state file: %w", err)
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("fsm: parse state file: %w", err)
+	}
+	for k, v := range raw {
+		key, err := parseKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("fsm: parse state file: %w", err)
+		}
+		s.states[key] = v
+	}
+	return s, nil
+}
+
+// GetState implements maxigobot.StateStorage.
+func (s *FileStorage) GetState(_ context.Context, key maxigobot.StateKey) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.states[key], nil
+}
+
+// SetState implements maxigobot.StateStorage.
+func (s *FileStorage) SetState(_ context.Context, key maxigobot.StateKey, state string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, had := s.states[key]
+	if state == "" {
+		delete(s.states, key)
+	} else {
+		s.states[key] = state
+	}
+
+	if err := s.flush(); err != nil {
+		// The file still holds the previous state; restore it in memory too.
+		if had {
+			s.states[key] = prev
+		} else {
+			delete(s.states, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// flush atomically rewrites the state file. Caller must hold s.mu.
+func (s *FileStorage) flush() error {
+	raw := make(map[string]string, len(s.states))
+	for k, v := range s.states {
+		raw[formatKey(k)] = v
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("fsm: encode states: %w", err)
+	}
+
+	if err := fileutil.WriteAtomic(s.path, data); err != nil {
+		return fmt.Errorf("fsm: write state file: %w", err)
+	}
+	return nil
+}
+
+func formatKey(k maxigobot.StateKey) string {
+	return strconv.FormatInt(k.ChatID, 10) + ":" + strconv.FormatInt(k.UserID, 10)
+}
+
+func parseKey(s string) (maxigobot.StateKey, error) {
+	chat, user, ok := strings.Cut(s, ":")
+	if !ok {
+		return maxigobot.StateKey{}, fmt.Errorf("invalid key %q", s)
+	}
+	chatID, err := strconv.ParseInt(chat, 10, 64)
+	if err != nil {
+		return maxigobot.StateKey{}, fmt.Errorf("invalid key %q: %w", s, err)
+	}
+	userID, err := strconv.ParseInt(user, 10, 64)
+	if err != nil {
+		return maxigobot.StateKey{}, fmt.Errorf("invalid key %q: %w", s, err)
+	}
+	return maxigobot.StateKey{ChatID: chatID, UserID: userID}, nil
+}
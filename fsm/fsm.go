@@ -0,0 +1,48 @@
+// Package fsm provides finite-state-machine helpers for multi-step
+// conversations: handler filters by state and StateStorage implementations.
+//
+// Enable state tracking on the bot, register state-specific handlers and
+// move between states with Context.SetState:
+//
+//	b, err := maxigobot.New(token, maxigobot.WithStateStorage(fsm.NewMemoryStorage()))
+//
+//	b.Handle("/register", func(c maxigobot.Context) error {
+//		if err := c.SetState("await_email"); err != nil {
+//			return err
+//		}
+//		return c.Send("Your email?")
+//	})
+//	b.HandleFiltered(maxigobot.OnText, fsm.InState("await_email"), func(c maxigobot.Context) error {
+//		// save c.Text() as email...
+//		return c.SetState("")
+//	})
+//	b.Handle(maxigobot.OnText, fallbackHandler)
+//
+// State-specific handlers take priority over the plain handler registered
+// for the same endpoint.
+package fsm
+
+import maxigobot "github.com/maxigo-bot/maxigo-bot"
+
+// AnyState matches any non-empty state when passed to InState.
+const AnyState = "*"
+
+// InState returns a handler filter that accepts the update only when the
+// conversation is in one of the given states. Otherwise the handler is
+// skipped and the router tries the next handler for the endpoint.
+//
+// Pass "" to match conversations without a state, or AnyState to match
+// conversations in any state.
+func InState(states ...string) maxigobot.Filter {
+	allowed := make(map[string]struct{}, len(states))
+	for _, s := range states {
+		allowed[s] = struct{}{}
+	}
+	_, matchAny := allowed[AnyState]
+
+	return func(c maxigobot.Context) bool {
+		state := c.State()
+		_, ok := allowed[state]
+		return ok || (matchAny && state != "")
+	}
+}
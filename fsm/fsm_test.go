@@ -0,0 +1,40 @@
+package fsm
+
+import (
+	"testing"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// stateContext is a minimal maxigobot.Context that only reports a state.
+type stateContext struct {
+	maxigobot.Context
+	state string
+}
+
+func (c *stateContext) State() string { return c.state }
+
+func TestInState(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []string
+		state  string
+		want   bool
+	}{
+		{"match", []string{"await_email"}, "await_email", true},
+		{"one of many", []string{"a", "b"}, "b", true},
+		{"mismatch", []string{"await_email"}, "await_name", false},
+		{"empty state matches empty", []string{""}, "", true},
+		{"empty state does not match named", []string{"a"}, "", false},
+		{"any state", []string{AnyState}, "whatever", true},
+		{"any state skips empty", []string{AnyState}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InState(tt.states...)(&stateContext{state: tt.state}); got != tt.want {
+				t.Errorf("InState(%q) = %v, want %v", tt.states, got, tt.want)
+			}
+		})
+	}
+}
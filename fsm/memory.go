@@ -0,0 +1,38 @@
+package fsm
+
+import (
+	"context"
+	"sync"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// MemoryStorage is an in-memory StateStorage. State is lost on restart.
+type MemoryStorage struct {
+	mu     sync.RWMutex
+	states map[maxigobot.StateKey]string
+}
+
+// NewMemoryStorage creates an empty in-memory state storage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{states: make(map[maxigobot.StateKey]string)}
+}
+
+// GetState implements maxigobot.StateStorage.
+func (s *MemoryStorage) GetState(_ context.Context, key maxigobot.StateKey) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.states[key], nil
+}
+
+// SetState implements maxigobot.StateStorage.
+func (s *MemoryStorage) SetState(_ context.Context, key maxigobot.StateKey, state string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state == "" {
+		delete(s.states, key)
+		return nil
+	}
+	s.states[key] = state
+	return nil
+}
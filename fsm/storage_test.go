@@ -0,0 +1,79 @@
+package fsm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+func testStorage(t *testing.T, s maxigobot.StateStorage) {
+	t.Helper()
+	ctx := context.Background()
+	key := maxigobot.StateKey{ChatID: -100, UserID: 42}
+
+	if got, err := s.GetState(ctx, key); err != nil || got != "" {
+		t.Fatalf("GetState on empty storage = (%q, %v), want empty", got, err)
+	}
+	if err := s.SetState(ctx, key, "await_email"); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+	if got, _ := s.GetState(ctx, key); got != "await_email" {
+		t.Errorf("GetState = %q, want %q", got, "await_email")
+	}
+	other := maxigobot.StateKey{ChatID: -100, UserID: 43}
+	if got, _ := s.GetState(ctx, other); got != "" {
+		t.Errorf("state leaked to another user: %q", got)
+	}
+	if err := s.SetState(ctx, key, ""); err != nil {
+		t.Fatalf("SetState reset: %v", err)
+	}
+	if got, _ := s.GetState(ctx, key); got != "" {
+		t.Errorf("GetState after reset = %q, want empty", got)
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, NewMemoryStorage())
+}
+
+func TestFileStorage(t *testing.T) {
+	s, err := NewFileStorage(filepath.Join(t.TempDir(), "states.json"))
+	if err != nil {
+		t.Fatalf("NewFileStorage: %v", err)
+	}
+	testStorage(t, s)
+}
+
+func TestFileStorage_persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "states.json")
+	key := maxigobot.StateKey{ChatID: 1, UserID: 2}
+
+	s, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("NewFileStorage: %v", err)
+	}
+	if err := s.SetState(context.Background(), key, "step2"); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+
+	reopened, err := NewFileStorage(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if got, _ := reopened.GetState(context.Background(), key); got != "step2" {
+		t.Errorf("state after reopen = %q, want %q", got, "step2")
+	}
+}
+
+func TestFileStorage_corruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "states.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStorage(path); err == nil {
+		t.Fatal("expected error for corrupt state file")
+	}
+}
@@ -1,6 +1,10 @@
 package maxigobot
 
-import "fmt"
+import (
+	"fmt"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
 
 // Group represents a handler group with an isolated middleware stack.
 // Handlers registered in a group inherit the group's middleware
@@ -9,6 +13,7 @@ type Group struct {
 	bot        *Bot
 	middleware []MiddlewareFunc
 	handlers   map[string]*handlerEntry
+	patterns   []*patternEntry
 }
 
 // Use appends middleware to the group's middleware stack.
@@ -18,20 +23,49 @@ func (g *Group) Use(middleware ...MiddlewareFunc) {
 
 // Handle registers a handler for the given endpoint within this group.
 func (g *Group) Handle(endpoint any, h HandlerFunc, m ...MiddlewareFunc) {
+	if tm, ok := textMatcher(endpoint); ok {
+		g.patterns = addPattern(g.patterns, tm, h, m)
+		return
+	}
 	key := endpointKey(endpoint)
-	g.handlers[key] = &handlerEntry{
-		handler:    h,
-		middleware: m,
+	g.bot.checkCallbackConflict(key)
+	addHandler(g.handlers, key, h, m)
+}
+
+// HandleFiltered registers a filtered handler for the given endpoint within
+// this group. See Bot.HandleFiltered.
+func (g *Group) HandleFiltered(endpoint any, f Filter, h HandlerFunc, m ...MiddlewareFunc) {
+	if tm, ok := textMatcher(endpoint); ok {
+		g.patterns = addPattern(g.patterns, tm, h, filterMiddleware(f, m))
+		return
 	}
+	key := endpointKey(endpoint)
+	g.bot.checkCallbackConflict(key)
+	addFiltered(g.handlers, key, f, h, m)
 }
 
 // endpointKey converts an endpoint to its map key.
-// Panics if endpoint is not a string, since Handle is called at setup time.
+// A callback button is registered as OnCallback with its payload.
+// Panics on other endpoint types, since Handle is called at setup time.
+// Pattern endpoints (TextMatcher, *regexp.Regexp) are handled separately.
 func endpointKey(endpoint any) string {
 	switch e := endpoint.(type) {
 	case string:
 		return e
+	case maxigo.Button:
+		return buttonKey(e)
+	case *maxigo.Button:
+		return buttonKey(*e)
 	default:
 		panic(fmt.Sprintf("maxigobot: unsupported endpoint type %T", endpoint))
 	}
 }
+
+// buttonKey returns the endpoint key of a callback button.
+// Panics for other button types, which never produce callbacks.
+func buttonKey(btn maxigo.Button) string {
+	if btn.Type != "callback" {
+		panic(fmt.Sprintf("maxigobot: cannot handle %q button %q", btn.Type, btn.Text))
+	}
+	return OnCallback(btn.Payload)
+}
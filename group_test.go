@@ -2,6 +2,8 @@ package maxigobot
 
 import (
 	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
 )
 
 func TestGroup_Handle(t *testing.T) {
@@ -19,9 +21,9 @@ func TestGroup_Handle(t *testing.T) {
 	}
 
 	// Verify it's findable through the bot.
-	entry, _ := b.findHandler("/test", nil)
+	entry, _ := firstHandler(b, "/test", nil)
 	if entry == nil {
-		t.Fatal("handler not found via bot.findHandler")
+		t.Fatal("handler not found via bot.findHandlers")
 	}
 
 	if err := entry.handler(nil); err != nil {
@@ -49,7 +51,7 @@ func TestGroup_Use(t *testing.T) {
 		return nil
 	})
 
-	entry, groupMW := b.findHandler("/test", nil)
+	entry, groupMW := firstHandler(b, "/test", nil)
 	if entry == nil {
 		t.Fatal("handler not found")
 	}
@@ -90,6 +92,8 @@ func TestEndpointKey(t *testing.T) {
 		{"/start", "/start"},
 		{OnText, OnText},
 		{OnCallback("x"), "\fx"},
+		{maxigo.NewCallbackButton("Buy", "buy"), "\fbuy"},
+		{ptr(maxigo.NewCallbackButton("Buy", "buy")), "\fbuy"},
 	}
 	for _, tt := range tests {
 		if got := endpointKey(tt.input); got != tt.want {
@@ -106,3 +110,12 @@ func TestEndpointKey_panicsOnInvalidType(t *testing.T) {
 	}()
 	endpointKey(42)
 }
+
+func TestEndpointKey_panicsOnNonCallbackButton(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic for link button endpoint")
+		}
+	}()
+	endpointKey(maxigo.NewLinkButton("Site", "https://example.com"))
+}
@@ -1,11 +1,34 @@
 package maxigobot
 
+import "errors"
+
+// ErrSkip can be returned by a handler or its middleware to decline an
+// update. The router then tries the next handler for the update. A
+// [Filter] declines updates it does not accept with ErrSkip.
+var ErrSkip = errors.New("maxigobot: handler skipped")
+
 // HandlerFunc defines a handler function for processing updates.
 type HandlerFunc func(c Context) error
 
 // MiddlewareFunc defines a middleware that wraps a handler.
 type MiddlewareFunc func(next HandlerFunc) HandlerFunc
 
+// Filter reports whether a handler registered with HandleFiltered accepts
+// the update in c (see fsm.InState).
+type Filter func(c Context) bool
+
+// middleware returns a middleware that declines the updates f rejects.
+func (f Filter) middleware() MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			if !f(c) {
+				return ErrSkip
+			}
+			return next(c)
+		}
+	}
+}
+
 // applyMiddleware wraps a handler with middleware in order.
 // Middleware is applied so that the first in the slice executes first (outermost).
 func applyMiddleware(h HandlerFunc, middleware ...MiddlewareFunc) HandlerFunc {
@@ -20,4 +43,57 @@ func applyMiddleware(h HandlerFunc, middleware ...MiddlewareFunc) HandlerFunc {
 type handlerEntry struct {
 	handler    HandlerFunc
 	middleware []MiddlewareFunc
+
+	// filtered holds handlers registered for the same endpoint with
+	// HandleFiltered. They are tried in registration order before handler,
+	// and each of them may decline the update with ErrSkip.
+	filtered []*handlerEntry
+}
+
+// addHandler registers a handler for key in m, replacing the previous one.
+// Filtered handlers registered for key are kept.
+func addHandler(m map[string]*handlerEntry, key string, h HandlerFunc, mw []MiddlewareFunc) {
+	entry, ok := m[key]
+	if !ok {
+		entry = &handlerEntry{}
+		m[key] = entry
+	}
+	entry.handler = h
+	entry.middleware = mw
+}
+
+// addFiltered registers a filtered handler for key in m next to the
+// existing handlers, so that filtered handlers (e.g. one per FSM state) can
+// share an endpoint with a catch-all handler.
+func addFiltered(m map[string]*handlerEntry, key string, f Filter, h HandlerFunc, mw []MiddlewareFunc) {
+	entry, ok := m[key]
+	if !ok {
+		entry = &handlerEntry{}
+		m[key] = entry
+	}
+	entry.filtered = append(entry.filtered, &handlerEntry{
+		handler:    h,
+		middleware: filterMiddleware(f, mw),
+	})
+}
+
+// filterMiddleware returns mw preceded by the middleware of f.
+func filterMiddleware(f Filter, mw []MiddlewareFunc) []MiddlewareFunc {
+	return append([]MiddlewareFunc{f.middleware()}, mw...)
+}
+
+// run executes the filtered handlers in order until one of them accepts the
+// update, then falls back to the plain handler. Returns ErrSkip if every
+// handler declined.
+func (e *handlerEntry) run(c Context) error {
+	for _, f := range e.filtered {
+		err := applyMiddleware(f.handler, f.middleware...)(c)
+		if !errors.Is(err, ErrSkip) {
+			return err
+		}
+	}
+	if e.handler == nil {
+		return ErrSkip
+	}
+	return applyMiddleware(e.handler, e.middleware...)(c)
 }
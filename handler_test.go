@@ -1,6 +1,7 @@
 package maxigobot
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -77,3 +78,64 @@ func TestApplyMiddleware_shortCircuit(t *testing.T) {
 		t.Fatal("handler should not have been called")
 	}
 }
+
+func TestAddHandler_plainReplaces(t *testing.T) {
+	m := make(map[string]*handlerEntry)
+	var got string
+	addHandler(m, "/x", func(c Context) error { got = "first"; return nil }, nil)
+	addHandler(m, "/x", func(c Context) error { got = "second"; return nil }, nil)
+
+	if err := m["/x"].run(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "second" {
+		t.Errorf("got %q, want %q", got, "second")
+	}
+}
+
+func TestAddHandler_replacesWithMiddleware(t *testing.T) {
+	m := make(map[string]*handlerEntry)
+	pass := func(next HandlerFunc) HandlerFunc { return next }
+
+	var got string
+	addHandler(m, "/x", func(c Context) error { got = "first"; return nil }, []MiddlewareFunc{pass})
+	addHandler(m, "/x", func(c Context) error { got = "second"; return nil }, nil)
+
+	if err := m["/x"].run(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "second" {
+		t.Errorf("got %q, want %q", got, "second")
+	}
+	if len(m["/x"].filtered) != 0 {
+		t.Errorf("filtered = %d, want 0", len(m["/x"].filtered))
+	}
+}
+
+func TestAddFiltered_beforePlain(t *testing.T) {
+	m := make(map[string]*handlerEntry)
+	reject := func(c Context) bool { return false }
+	accept := func(c Context) bool { return true }
+
+	var got []string
+	addHandler(m, OnText, func(c Context) error { got = append(got, "plain"); return nil }, nil)
+	addFiltered(m, OnText, reject, func(c Context) error { got = append(got, "skipped"); return nil }, nil)
+	addFiltered(m, OnText, accept, func(c Context) error { got = append(got, "filtered"); return nil }, nil)
+
+	if err := m[OnText].run(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0] != "filtered" {
+		t.Errorf("got %v, want [filtered]", got)
+	}
+}
+
+func TestHandlerEntry_run_allSkip(t *testing.T) {
+	m := make(map[string]*handlerEntry)
+	reject := func(c Context) bool { return false }
+	addFiltered(m, OnText, reject, func(c Context) error { return nil }, nil)
+
+	if err := m[OnText].run(nil); !errors.Is(err, ErrSkip) {
+		t.Errorf("error = %v, want ErrSkip", err)
+	}
+}
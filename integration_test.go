@@ -2,6 +2,7 @@ package maxigobot
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -153,6 +154,70 @@ func TestIntegration_Delete(t *testing.T) {
 	}
 }
 
+func TestIntegration_SendMessage_returnsMessage(t *testing.T) {
+	var gotReplyTo any
+
+	b := testBotWithServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if link, ok := body["link"].(map[string]any); ok {
+			gotReplyTo = link["mid"]
+		}
+		writeJSON(t, w, `{"message":{"recipient":{"chat_id":100,"chat_type":"dialog"},"timestamp":1,"body":{"mid":"sent1","seq":1}}}`)
+	})
+
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+	ctx.update.(*maxigo.MessageCreatedUpdate).Message.Body.MID = "orig1"
+
+	msg, err := ctx.SendMessage("hello")
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if msg == nil || msg.Body.MID != "sent1" {
+		t.Fatalf("SendMessage returned %+v, want MID sent1", msg)
+	}
+
+	msg, err = ctx.ReplyMessage("reply")
+	if err != nil {
+		t.Fatalf("ReplyMessage: %v", err)
+	}
+	if msg.Body.MID != "sent1" || gotReplyTo != "orig1" {
+		t.Errorf("ReplyMessage MID = %q, reply to %v", msg.Body.MID, gotReplyTo)
+	}
+}
+
+func TestIntegration_SendMessage_noChatID(t *testing.T) {
+	b := testBotWithServer(t, func(w http.ResponseWriter, r *http.Request) {})
+	ctx := newTestContext(b, &maxigo.MessageChatCreatedUpdate{})
+
+	msg, err := ctx.SendMessage("hello")
+	if msg != nil || !errors.Is(err, ErrNoChatID) {
+		t.Errorf("SendMessage = %v, %v; want nil, ErrNoChatID", msg, err)
+	}
+}
+
+func TestIntegration_EditDeleteMessage(t *testing.T) {
+	var got []string
+
+	b := testBotWithServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Query().Get("message_id"))
+		writeJSON(t, w, `{"success":true}`)
+	})
+
+	// Works without a current message, e.g. from a bot_started handler.
+	ctx := newTestContext(b, &maxigo.BotStartedUpdate{ChatID: 100})
+	if err := ctx.EditMessage("m42", "updated"); err != nil {
+		t.Fatalf("EditMessage: %v", err)
+	}
+	if err := ctx.DeleteMessage("m42"); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	want := []string{"PUT m42", "DELETE m42"}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("requests = %q, want %q", got, want)
+	}
+}
+
 func TestIntegration_Delete_noMessage(t *testing.T) {
 	b := testBotWithServer(t, func(w http.ResponseWriter, r *http.Request) {})
 
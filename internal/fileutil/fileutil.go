@@ -0,0 +1,37 @@
+// Package fileutil contains file helpers shared by the file-backed stores of
+// maxigo-bot and its subpackages.
+package fileutil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never observe a partially written file.
+func WriteAtomic(path string, data []byte) error {
+	return WriteAtomicFunc(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// WriteAtomicFunc is like WriteAtomic but streams the content from write.
+// The temporary file is removed if write fails.
+func WriteAtomicFunc(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
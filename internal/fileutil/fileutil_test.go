@@ -0,0 +1,53 @@
+package fileutil
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAtomic_replaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := WriteAtomic(path, []byte("old")); err != nil {
+		t.Fatalf("WriteAtomic: %v", err)
+	}
+	if err := WriteAtomic(path, []byte("new")); err != nil {
+		t.Fatalf("WriteAtomic: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != "new" {
+		t.Errorf("content = %q, want %q", data, "new")
+	}
+}
+
+func TestWriteAtomicFunc_failedWriteKeepsFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+	if err := WriteAtomic(path, []byte("old")); err != nil {
+		t.Fatalf("WriteAtomic: %v", err)
+	}
+
+	boom := errors.New("boom")
+	err := WriteAtomicFunc(path, func(w io.Writer) error {
+		_, _ = w.Write([]byte("partial"))
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want %v", err, boom)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "old" {
+		t.Errorf("content = %q, want %q", data, "old")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("dir has %d entries, want only the target file", len(entries))
+	}
+}
@@ -0,0 +1,161 @@
+package maxigobot
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// Inline keyboard limits enforced by the Max API.
+const (
+	// MaxKeyboardRows is the maximum number of rows in an inline keyboard.
+	MaxKeyboardRows = 30
+	// MaxKeyboardButtons is the maximum total number of buttons in an inline keyboard.
+	MaxKeyboardButtons = 210
+	// MaxRowButtons is the maximum number of buttons in a row.
+	MaxRowButtons = 7
+	// MaxRowWideButtons is the maximum number of buttons in a row that contains
+	// a link, open_app, request_geo_location or request_contact button.
+	MaxRowWideButtons = 3
+	// MaxButtonTextLen is the maximum button text length in characters.
+	MaxButtonTextLen = 128
+	// MaxButtonPayloadLen is the maximum callback/clipboard payload length in bytes.
+	MaxButtonPayloadLen = 1024
+)
+
+// ErrInvalidKeyboard is returned when a keyboard exceeds the Max API limits.
+var ErrInvalidKeyboard = errors.New("maxigobot: invalid keyboard")
+
+// Keyboard builds an inline keyboard. It is an immutable value: Row and Grid
+// return a new Keyboard, so a base keyboard can be shared and extended
+// without affecting other copies.
+//
+//	var kb maxigobot.Keyboard
+//	btnBuy := kb.Callback("Buy", "buy")
+//	menu := kb.Row(btnBuy, kb.Link("Site", "https://example.com"))
+//
+//	b.Handle(btnBuy, onBuy)
+//	att, err := menu.Attachment()
+//	c.Send("Menu", maxigobot.WithAttachments(att))
+type Keyboard struct {
+	rows [][]maxigo.Button
+}
+
+// NewKeyboard creates a keyboard with the given rows.
+func NewKeyboard(rows ...[]maxigo.Button) Keyboard {
+	var kb Keyboard
+	for _, row := range rows {
+		kb = kb.Row(row...)
+	}
+	return kb
+}
+
+// Row returns a copy of the keyboard with a row of buttons appended.
+func (kb Keyboard) Row(buttons ...maxigo.Button) Keyboard {
+	return Keyboard{rows: append(slices.Clip(kb.rows), slices.Clone(buttons))}
+}
+
+// Grid returns a copy of the keyboard with buttons laid out in rows of
+// cols buttons each. The last row may be shorter. cols <= 0 puts all
+// buttons in a single row.
+func (kb Keyboard) Grid(buttons []maxigo.Button, cols int) Keyboard {
+	if cols <= 0 {
+		cols = len(buttons)
+	}
+	for chunk := range slices.Chunk(buttons, max(cols, 1)) {
+		kb = kb.Row(chunk...)
+	}
+	return kb
+}
+
+// Rows returns a copy of the keyboard rows.
+func (kb Keyboard) Rows() [][]maxigo.Button {
+	rows := make([][]maxigo.Button, len(kb.rows))
+	for i, row := range kb.rows {
+		rows[i] = slices.Clone(row)
+	}
+	return rows
+}
+
+// Callback creates a callback button. The button can be passed to Handle
+// to register a handler for its payload.
+func (Keyboard) Callback(text, data string) maxigo.Button {
+	return maxigo.NewCallbackButton(text, data)
+}
+
+// Link creates a button that opens url.
+func (Keyboard) Link(text, url string) maxigo.Button {
+	return maxigo.NewLinkButton(text, url)
+}
+
+// RequestContact creates a button that asks the user to share their contact.
+func (Keyboard) RequestContact(text string) maxigo.Button {
+	return maxigo.NewRequestContactButton(text)
+}
+
+// Clipboard creates a button that copies payload to the user's clipboard.
+func (Keyboard) Clipboard(text, payload string) maxigo.Button {
+	return maxigo.NewClipboardButton(text, payload)
+}
+
+// Validate checks the keyboard against the Max API limits.
+// The returned error wraps ErrInvalidKeyboard.
+func (kb Keyboard) Validate() error {
+	if len(kb.rows) == 0 {
+		return fmt.Errorf("%w: no buttons", ErrInvalidKeyboard)
+	}
+	if len(kb.rows) > MaxKeyboardRows {
+		return fmt.Errorf("%w: %d rows, max %d", ErrInvalidKeyboard, len(kb.rows), MaxKeyboardRows)
+	}
+
+	total := 0
+	for i, row := range kb.rows {
+		if len(row) == 0 {
+			return fmt.Errorf("%w: row %d is empty", ErrInvalidKeyboard, i)
+		}
+		limit := MaxRowButtons
+		for j, btn := range row {
+			if isWideButton(btn.Type) {
+				limit = MaxRowWideButtons
+			}
+			if btn.Text == "" {
+				return fmt.Errorf("%w: button %d:%d has no text", ErrInvalidKeyboard, i, j)
+			}
+			if n := utf8.RuneCountInString(btn.Text); n > MaxButtonTextLen {
+				return fmt.Errorf("%w: button %d:%d text is %d characters, max %d", ErrInvalidKeyboard, i, j, n, MaxButtonTextLen)
+			}
+			if len(btn.Payload) > MaxButtonPayloadLen {
+				return fmt.Errorf("%w: button %d:%d payload is %d bytes, max %d", ErrInvalidKeyboard, i, j, len(btn.Payload), MaxButtonPayloadLen)
+			}
+		}
+		if len(row) > limit {
+			return fmt.Errorf("%w: row %d has %d buttons, max %d", ErrInvalidKeyboard, i, len(row), limit)
+		}
+		total += len(row)
+	}
+	if total > MaxKeyboardButtons {
+		return fmt.Errorf("%w: %d buttons, max %d", ErrInvalidKeyboard, total, MaxKeyboardButtons)
+	}
+	return nil
+}
+
+// Attachment validates the keyboard and returns it as an inline keyboard
+// attachment for WithAttachments.
+func (kb Keyboard) Attachment() (maxigo.AttachmentRequest, error) {
+	if err := kb.Validate(); err != nil {
+		return maxigo.AttachmentRequest{}, err
+	}
+	return maxigo.NewInlineKeyboardAttachment(kb.Rows()), nil
+}
+
+// isWideButton reports whether the button type limits its row to MaxRowWideButtons.
+func isWideButton(typ string) bool {
+	switch typ {
+	case "link", "open_app", "request_geo_location", "request_contact":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package maxigobot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+func TestKeyboard_Row(t *testing.T) {
+	var kb Keyboard
+	base := kb.Row(kb.Callback("A", "a"))
+	first := base.Row(kb.Callback("B", "b"))
+	second := base.Row(kb.Link("C", "https://c"))
+
+	if len(base.Rows()) != 1 {
+		t.Errorf("base rows = %d, want 1", len(base.Rows()))
+	}
+	if got := first.Rows()[1][0].Text; got != "B" {
+		t.Errorf("first row 1 = %q, want B", got)
+	}
+	if got := second.Rows()[1][0].Text; got != "C" {
+		t.Errorf("second row 1 = %q, want C", got)
+	}
+}
+
+func TestKeyboard_Grid(t *testing.T) {
+	var kb Keyboard
+	var buttons []maxigo.Button
+	for i := range 7 {
+		buttons = append(buttons, kb.Callback(string(rune('a'+i)), "x"))
+	}
+
+	tests := []struct {
+		cols int
+		want []int
+	}{
+		{3, []int{3, 3, 1}},
+		{7, []int{7}},
+		{0, []int{7}},
+	}
+	for _, tt := range tests {
+		rows := kb.Grid(buttons, tt.cols).Rows()
+		if len(rows) != len(tt.want) {
+			t.Fatalf("cols=%d: rows = %d, want %d", tt.cols, len(rows), len(tt.want))
+		}
+		for i, n := range tt.want {
+			if len(rows[i]) != n {
+				t.Errorf("cols=%d: row %d has %d buttons, want %d", tt.cols, i, len(rows[i]), n)
+			}
+		}
+	}
+}
+
+func TestKeyboard_buttons(t *testing.T) {
+	var kb Keyboard
+	tests := []struct {
+		btn  maxigo.Button
+		typ  string
+		want string
+	}{
+		{kb.Callback("Buy", "buy"), "callback", "buy"},
+		{kb.Link("Site", "https://example.com"), "link", ""},
+		{kb.RequestContact("Phone"), "request_contact", ""},
+		{kb.Clipboard("Copy", "PROMO"), "clipboard", "PROMO"},
+	}
+	for _, tt := range tests {
+		if tt.btn.Type != tt.typ || tt.btn.Payload != tt.want {
+			t.Errorf("button = %+v, want type %q payload %q", tt.btn, tt.typ, tt.want)
+		}
+	}
+}
+
+func TestKeyboard_Validate(t *testing.T) {
+	var kb Keyboard
+	btn := kb.Callback("OK", "ok")
+	link := kb.Link("Site", "https://example.com")
+
+	many := func(n int) []maxigo.Button {
+		buttons := make([]maxigo.Button, n)
+		for i := range buttons {
+			buttons[i] = btn
+		}
+		return buttons
+	}
+
+	tests := []struct {
+		name    string
+		kb      Keyboard
+		wantErr bool
+	}{
+		{"valid", kb.Row(btn, btn).Row(link), false},
+		{"empty", kb, true},
+		{"empty row", kb.Row(), true},
+		{"row limit", kb.Row(many(7)...), false},
+		{"too many in row", kb.Row(many(8)...), true},
+		{"wide row limit", kb.Row(link, btn, btn), false},
+		{"too many with link", kb.Row(link, btn, btn, btn), true},
+		{"too many rows", kb.Grid(many(31), 1), true},
+		{"too many buttons", kb.Grid(many(211), 7), true},
+		{"max buttons", kb.Grid(many(210), 7), false},
+		{"no text", kb.Row(kb.Callback("", "x")), true},
+		{"long text", kb.Row(kb.Callback(strings.Repeat("я", MaxButtonTextLen+1), "x")), true},
+		{"max text", kb.Row(kb.Callback(strings.Repeat("я", MaxButtonTextLen), "x")), false},
+		{"long payload", kb.Row(kb.Callback("x", strings.Repeat("a", MaxButtonPayloadLen+1))), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.kb.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidKeyboard) {
+				t.Errorf("error %v does not wrap ErrInvalidKeyboard", err)
+			}
+		})
+	}
+}
+
+func TestKeyboard_Attachment(t *testing.T) {
+	var kb Keyboard
+	att, err := NewKeyboard([]maxigo.Button{kb.Callback("A", "a")}).Attachment()
+	if err != nil {
+		t.Fatalf("Attachment: %v", err)
+	}
+	if att.Type != "inline_keyboard" {
+		t.Errorf("Type = %q, want inline_keyboard", att.Type)
+	}
+	payload, ok := att.Payload.(maxigo.Keyboard)
+	if !ok || len(payload.Buttons) != 1 || payload.Buttons[0][0].Payload != "a" {
+		t.Errorf("Payload = %+v", att.Payload)
+	}
+
+	if _, err := kb.Attachment(); !errors.Is(err, ErrInvalidKeyboard) {
+		t.Errorf("empty keyboard Attachment() = %v, want ErrInvalidKeyboard", err)
+	}
+}
+
+func TestHandle_button(t *testing.T) {
+	b, _ := New("token")
+	var kb Keyboard
+	btn := kb.Callback("Buy", "buy")
+
+	called := false
+	b.Handle(btn, func(c Context) error { called = true; return nil })
+
+	ctx := newTestContext(b, callbackUpdate("buy:1"))
+	entry, _ := firstRoute(b, ctx, OnCallback("buy:1"))
+	if entry == nil {
+		t.Fatal("button handler not found")
+	}
+	_ = entry.run(ctx)
+	if !called {
+		t.Error("button handler not called")
+	}
+}
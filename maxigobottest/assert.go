@@ -0,0 +1,90 @@
+package maxigobottest
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func (s *Server) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+	return DefaultTimeout
+}
+
+// WaitSent waits until at least n messages have been sent and returns all
+// sent messages. It fails the test on timeout.
+func (s *Server) WaitSent(tb testing.TB, n int) []SentMessage {
+	tb.Helper()
+	waitUntil(tb, s.timeout(), fmt.Sprintf("%d sent messages (got %d)", n, len(s.Sent())), func() bool {
+		return len(s.Sent()) >= n
+	})
+	return s.Sent()
+}
+
+// WaitEdits waits until at least n messages have been edited.
+func (s *Server) WaitEdits(tb testing.TB, n int) []EditedMessage {
+	tb.Helper()
+	waitUntil(tb, s.timeout(), fmt.Sprintf("%d edits", n), func() bool {
+		return len(s.Edits()) >= n
+	})
+	return s.Edits()
+}
+
+// WaitAnswers waits until at least n callbacks have been answered.
+func (s *Server) WaitAnswers(tb testing.TB, n int) []CallbackAnswer {
+	tb.Helper()
+	waitUntil(tb, s.timeout(), fmt.Sprintf("%d callback answers", n), func() bool {
+		return len(s.Answers()) >= n
+	})
+	return s.Answers()
+}
+
+// WaitUploads waits until at least n files have been uploaded.
+func (s *Server) WaitUploads(tb testing.TB, n int) []Upload {
+	tb.Helper()
+	waitUntil(tb, s.timeout(), fmt.Sprintf("%d uploads", n), func() bool {
+		return len(s.Uploads()) >= n
+	})
+	return s.Uploads()
+}
+
+// AssertSent waits for the bot to send len(texts) messages and checks
+// that the texts of all sent messages are exactly texts, in order.
+func (s *Server) AssertSent(tb testing.TB, texts ...string) {
+	tb.Helper()
+	sent := s.WaitSent(tb, len(texts))
+	got := make([]string, len(sent))
+	for i, m := range sent {
+		got[i] = m.Text
+	}
+	if !slices.Equal(got, texts) {
+		tb.Errorf("maxigobottest: sent texts = %q, want %q", got, texts)
+	}
+}
+
+// AssertSentTo waits for the n-th (0-based) sent message and checks its
+// chat and text.
+func (s *Server) AssertSentTo(tb testing.TB, n int, chatID int64, text string) {
+	tb.Helper()
+	m := s.WaitSent(tb, n+1)[n]
+	if m.ChatID != chatID || m.Text != text {
+		tb.Errorf("maxigobottest: message %d = %q to chat %d, want %q to chat %d", n, m.Text, m.ChatID, text, chatID)
+	}
+}
+
+// AssertAnswered waits for len(notifications) callback answers and checks
+// that their notifications are exactly notifications, in order.
+func (s *Server) AssertAnswered(tb testing.TB, notifications ...string) {
+	tb.Helper()
+	answers := s.WaitAnswers(tb, len(notifications))
+	got := make([]string, len(answers))
+	for i, a := range answers {
+		got[i] = a.Notification
+	}
+	if !slices.Equal(got, notifications) {
+		tb.Errorf("maxigobottest: callback answers = %q, want %q", got, notifications)
+	}
+}
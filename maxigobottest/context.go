@@ -0,0 +1,567 @@
+package maxigobottest
+
+import (
+	gocontext "context"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// Call is a Context method call recorded by the Context test double.
+type Call struct {
+	// Method is the name of the called method, e.g. "Send" or "EditMessage".
+	Method string
+	// Text is the message text or the callback answer.
+	Text string
+	// MID is the message ID of Edit, EditMessage, Delete and DeleteMessage
+	// calls, and the message ID returned by SendMessage and ReplyMessage.
+	MID string
+	// Action is the action of a Notify call.
+	Action maxigo.SenderAction
+	// Config holds the decoded send options. Methods that add media or
+	// attachments themselves (SendVideo, SendSticker, ...) append them
+	// like the real Context does, and replies carry ReplyTo.
+	Config maxigobot.SendConfig
+}
+
+// Context is a maxigobot.Context test double for unit-testing handlers and
+// middleware without a bot or a server. It records the calls that talk to
+// the Max API and returns the values set with its With methods:
+//
+//	c := maxigobottest.NewContext().WithChat(100).WithText("/start")
+//	if err := handler(c); err != nil {
+//		t.Fatal(err)
+//	}
+//	calls := c.CallsTo("Send")
+//	if len(calls) != 1 || calls[0].Text != "Hello!" {
+//		t.Errorf("calls = %+v", c.Calls())
+//	}
+//
+// Like the real Context, sending requires a chat, Edit and Delete require a
+// message and Respond requires a callback. A Context is safe for concurrent
+// use, but the With methods must not be called concurrently with the
+// handler under test.
+type Context struct {
+	bot          *maxigobot.Bot
+	ctx          gocontext.Context
+	update       maxigo.Update
+	sender       *maxigo.User
+	chatID       int64
+	message      *maxigo.Message
+	command      string
+	payload      string
+	match        []string
+	callback     *maxigo.Callback
+	callbackArgs []string
+	attachments  []maxigo.Attachment
+	verified     bool
+	download     []byte
+	stateStorage bool
+	state        string
+	mu           sync.Mutex
+	store        map[string]any
+	calls        []Call
+	errs         map[string]error
+	nextMID      int
+}
+
+var _ maxigobot.Context = (*Context)(nil)
+
+// NewContext returns an empty Context. Use the With methods to configure it.
+func NewContext() *Context {
+	return &Context{}
+}
+
+// WithBot sets the value returned by Bot and API.
+func (c *Context) WithBot(b *maxigobot.Bot) *Context {
+	c.bot = b
+	return c
+}
+
+// WithCtx sets the value returned by Ctx (context.Background by default).
+func (c *Context) WithCtx(ctx gocontext.Context) *Context {
+	c.ctx = ctx
+	return c
+}
+
+// WithUpdate sets the value returned by Update.
+func (c *Context) WithUpdate(u maxigo.Update) *Context {
+	c.update = u
+	return c
+}
+
+// WithSender sets the sender to a user with userID.
+func (c *Context) WithSender(userID int64) *Context {
+	return c.WithUser(&maxigo.User{UserID: userID, FirstName: "User " + strconv.FormatInt(userID, 10)})
+}
+
+// WithUser sets the value returned by Sender.
+func (c *Context) WithUser(u *maxigo.User) *Context {
+	c.sender = u
+	return c
+}
+
+// WithChat sets the value returned by Chat.
+func (c *Context) WithChat(chatID int64) *Context {
+	c.chatID = chatID
+	return c
+}
+
+// WithMessage sets the value returned by Message.
+func (c *Context) WithMessage(msg *maxigo.Message) *Context {
+	c.message = msg
+	return c
+}
+
+// WithText sets the text of the current message, creating the message if
+// needed. A text starting with "/" also sets Command and Payload the way
+// the router does: "/start:ref42" has command "start" and payload "ref42".
+func (c *Context) WithText(text string) *Context {
+	if c.message == nil {
+		c.message = &maxigo.Message{Body: maxigo.MessageBody{MID: nextID("user.mid.")}}
+	}
+	c.message.Body.Text = &text
+	if cmd, ok := strings.CutPrefix(text, "/"); ok {
+		c.command, c.payload, _ = strings.Cut(cmd, ":")
+	}
+	return c
+}
+
+// WithCommand sets the values returned by Command and Payload.
+func (c *Context) WithCommand(command, payload string) *Context {
+	c.command, c.payload = command, payload
+	return c
+}
+
+// WithPayload sets the value returned by Payload.
+func (c *Context) WithPayload(payload string) *Context {
+	c.payload = payload
+	return c
+}
+
+// WithMatch sets the value returned by Match.
+func (c *Context) WithMatch(match ...string) *Context {
+	c.match = match
+	return c
+}
+
+// WithCallback makes the context a callback with the given payload, pressed
+// by the sender if set. args are returned by CallbackArgs.
+func (c *Context) WithCallback(payload string, args ...string) *Context {
+	cb := &maxigo.Callback{CallbackID: nextID("cb."), Payload: payload}
+	if c.sender != nil {
+		cb.User = *c.sender
+	}
+	c.callback = cb
+	c.callbackArgs = args
+	return c
+}
+
+// WithAttachments sets the value returned by Attachments. Photos, Files,
+// Location and Contact filter them.
+func (c *Context) WithAttachments(atts ...maxigo.Attachment) *Context {
+	c.attachments = atts
+	return c
+}
+
+// WithVerifiedContact makes Contact report its contact as verified.
+func (c *Context) WithVerifiedContact() *Context {
+	c.verified = true
+	return c
+}
+
+// WithDownload sets the content that Download writes for any attachment.
+func (c *Context) WithDownload(data []byte) *Context {
+	c.download = data
+	return c
+}
+
+// WithState sets the conversation state returned by State and enables
+// SetState, as if the bot had a state storage.
+func (c *Context) WithState(state string) *Context {
+	c.stateStorage = true
+	c.state = state
+	return c
+}
+
+// Fail makes the calls to method (e.g. "Send") return err. The calls are
+// still recorded. A nil err removes the failure.
+func (c *Context) Fail(method string, err error) *Context {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.errs == nil {
+		c.errs = make(map[string]error)
+	}
+	c.errs[method] = err
+	return c
+}
+
+// Calls returns the recorded calls in order.
+func (c *Context) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.calls)
+}
+
+// CallsTo returns the recorded calls to method.
+func (c *Context) CallsTo(method string) []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []Call
+	for _, call := range c.calls {
+		if call.Method == method {
+			out = append(out, call)
+		}
+	}
+	return out
+}
+
+// Reset clears the recorded calls.
+func (c *Context) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = nil
+}
+
+// record records call and returns the error set with Fail for its method.
+func (c *Context) record(call Call) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, call)
+	return c.errs[call.Method]
+}
+
+func (c *Context) Bot() *maxigobot.Bot        { return c.bot }
+func (c *Context) Update() maxigo.Update      { return c.update }
+func (c *Context) Sender() *maxigo.User       { return c.sender }
+func (c *Context) Chat() int64                { return c.chatID }
+func (c *Context) Message() *maxigo.Message   { return c.message }
+func (c *Context) Command() string            { return c.command }
+func (c *Context) Payload() string            { return c.payload }
+func (c *Context) Match() []string            { return c.match }
+func (c *Context) Callback() *maxigo.Callback { return c.callback }
+func (c *Context) CallbackArgs() []string     { return c.callbackArgs }
+
+func (c *Context) API() *maxigo.Client {
+	if c.bot != nil {
+		return c.bot.Client()
+	}
+	return nil
+}
+
+func (c *Context) Ctx() gocontext.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return gocontext.Background()
+}
+
+func (c *Context) SetCtx(ctx gocontext.Context) { c.ctx = ctx }
+
+func (c *Context) Text() string {
+	if c.message != nil && c.message.Body.Text != nil {
+		return *c.message.Body.Text
+	}
+	return ""
+}
+
+func (c *Context) Args() []string {
+	if c.payload == "" {
+		return nil
+	}
+	return strings.Fields(c.payload)
+}
+
+func (c *Context) Data() string {
+	if c.callback != nil {
+		return c.callback.Payload
+	}
+	return ""
+}
+
+func (c *Context) Attachments() []maxigo.Attachment { return c.attachments }
+
+func (c *Context) Photos() []*maxigo.PhotoAttachment {
+	return attachmentsOf[*maxigo.PhotoAttachment](c.attachments)
+}
+
+func (c *Context) Files() []*maxigo.FileAttachment {
+	return attachmentsOf[*maxigo.FileAttachment](c.attachments)
+}
+
+func (c *Context) Location() *maxigo.LocationAttachment {
+	if locs := attachmentsOf[*maxigo.LocationAttachment](c.attachments); len(locs) > 0 {
+		return locs[0]
+	}
+	return nil
+}
+
+func (c *Context) Contact() (*maxigo.ContactAttachment, bool) {
+	if contacts := attachmentsOf[*maxigo.ContactAttachment](c.attachments); len(contacts) > 0 {
+		return contacts[0], c.verified
+	}
+	return nil, false
+}
+
+func (c *Context) Download(att maxigo.Attachment, w io.Writer) error {
+	if err := c.record(Call{Method: "Download"}); err != nil {
+		return err
+	}
+	_, err := w.Write(c.download)
+	return err
+}
+
+func (c *Context) Send(text string, opts ...maxigobot.SendOption) error {
+	_, err := c.send("Send", text, opts)
+	return err
+}
+
+func (c *Context) SendMessage(text string, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.send("SendMessage", text, opts)
+}
+
+func (c *Context) Reply(text string, opts ...maxigobot.SendOption) error {
+	_, err := c.send("Reply", text, c.replyOpts(opts))
+	return err
+}
+
+func (c *Context) ReplyMessage(text string, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.send("ReplyMessage", text, c.replyOpts(opts))
+}
+
+func (c *Context) Edit(text string, opts ...maxigobot.SendOption) error {
+	if c.message == nil {
+		return &maxigobot.BotError{Err: maxigobot.ErrNoMessage}
+	}
+	return c.record(Call{
+		Method: "Edit",
+		Text:   text,
+		MID:    c.message.Body.MID,
+		Config: maxigobot.ApplySendOptions(opts...),
+	})
+}
+
+func (c *Context) EditMessage(mid, text string, opts ...maxigobot.SendOption) error {
+	return c.record(Call{
+		Method: "EditMessage",
+		Text:   text,
+		MID:    mid,
+		Config: maxigobot.ApplySendOptions(opts...),
+	})
+}
+
+func (c *Context) Delete() error {
+	if c.message == nil {
+		return &maxigobot.BotError{Err: maxigobot.ErrNoMessage}
+	}
+	return c.record(Call{Method: "Delete", MID: c.message.Body.MID})
+}
+
+func (c *Context) DeleteMessage(mid string) error {
+	return c.record(Call{Method: "DeleteMessage", MID: mid})
+}
+
+func (c *Context) SendPhoto(photo *maxigo.PhotoAttachmentRequestPayload, opts ...maxigobot.SendOption) error {
+	_, err := c.sendPhoto("SendPhoto", photo, opts)
+	return err
+}
+
+func (c *Context) SendPhotoMessage(photo *maxigo.PhotoAttachmentRequestPayload, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.sendPhoto("SendPhotoMessage", photo, opts)
+}
+
+func (c *Context) SendVideo(f maxigobot.File, opts ...maxigobot.SendOption) error {
+	_, err := c.sendMedia("SendVideo", opts, maxigobot.Video(f))
+	return err
+}
+
+func (c *Context) SendVideoMessage(f maxigobot.File, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.sendMedia("SendVideoMessage", opts, maxigobot.Video(f))
+}
+
+func (c *Context) SendAudio(f maxigobot.File, opts ...maxigobot.SendOption) error {
+	_, err := c.sendMedia("SendAudio", opts, maxigobot.Audio(f))
+	return err
+}
+
+func (c *Context) SendAudioMessage(f maxigobot.File, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.sendMedia("SendAudioMessage", opts, maxigobot.Audio(f))
+}
+
+func (c *Context) SendFile(f maxigobot.File, opts ...maxigobot.SendOption) error {
+	_, err := c.sendMedia("SendFile", opts, maxigobot.Document(f))
+	return err
+}
+
+func (c *Context) SendFileMessage(f maxigobot.File, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.sendMedia("SendFileMessage", opts, maxigobot.Document(f))
+}
+
+func (c *Context) SendAlbum(media []maxigobot.Media, opts ...maxigobot.SendOption) error {
+	_, err := c.sendMedia("SendAlbum", opts, media...)
+	return err
+}
+
+func (c *Context) SendAlbumMessage(media []maxigobot.Media, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.sendMedia("SendAlbumMessage", opts, media...)
+}
+
+func (c *Context) SendSticker(code string, opts ...maxigobot.SendOption) error {
+	_, err := c.sendAttachments("SendSticker", opts, stickerAttachment(code))
+	return err
+}
+
+func (c *Context) SendStickerMessage(code string, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.sendAttachments("SendStickerMessage", opts, stickerAttachment(code))
+}
+
+func (c *Context) SendLocation(latitude, longitude float64, opts ...maxigobot.SendOption) error {
+	_, err := c.sendAttachments("SendLocation", opts, maxigo.NewLocationAttachment(latitude, longitude))
+	return err
+}
+
+func (c *Context) SendLocationMessage(latitude, longitude float64, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.sendAttachments("SendLocationMessage", opts, maxigo.NewLocationAttachment(latitude, longitude))
+}
+
+func (c *Context) SendContact(name, phone string, opts ...maxigobot.SendOption) error {
+	_, err := c.sendAttachments("SendContact", opts, contactAttachment(name, phone))
+	return err
+}
+
+func (c *Context) SendContactMessage(name, phone string, opts ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return c.sendAttachments("SendContactMessage", opts, contactAttachment(name, phone))
+}
+
+func (c *Context) Respond(text string) error {
+	return c.respond("Respond", text)
+}
+
+func (c *Context) RespondAlert(text string) error {
+	return c.respond("RespondAlert", text)
+}
+
+func (c *Context) Notify(action maxigo.SenderAction) error {
+	if c.chatID == 0 {
+		return &maxigobot.BotError{Err: maxigobot.ErrNoChatID}
+	}
+	return c.record(Call{Method: "Notify", Action: action})
+}
+
+func (c *Context) Get(key string) any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.store[key]
+}
+
+func (c *Context) Set(key string, val any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.store == nil {
+		c.store = make(map[string]any)
+	}
+	c.store[key] = val
+}
+
+func (c *Context) State() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (c *Context) SetState(state string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.stateStorage {
+		return &maxigobot.BotError{Err: maxigobot.ErrNoStateStorage}
+	}
+	c.state = state
+	return nil
+}
+
+// send records a message sent to the current chat and returns it.
+func (c *Context) send(method, text string, opts []maxigobot.SendOption) (*maxigo.Message, error) {
+	if c.chatID == 0 {
+		return nil, &maxigobot.BotError{Err: maxigobot.ErrNoChatID}
+	}
+	c.mu.Lock()
+	c.nextMID++
+	mid := "mock.mid." + strconv.Itoa(c.nextMID)
+	c.mu.Unlock()
+
+	err := c.record(Call{
+		Method: method,
+		Text:   text,
+		MID:    mid,
+		Config: maxigobot.ApplySendOptions(opts...),
+	})
+	if err != nil {
+		return nil, err
+	}
+	chatID := c.chatID
+	return &maxigo.Message{
+		Recipient: maxigo.Recipient{ChatID: &chatID},
+		Body:      maxigo.MessageBody{MID: mid, Text: &text},
+	}, nil
+}
+
+// replyOpts prepends WithReplyTo for the current message, like the real
+// Context does.
+func (c *Context) replyOpts(opts []maxigobot.SendOption) []maxigobot.SendOption {
+	if c.message == nil {
+		return opts
+	}
+	return append([]maxigobot.SendOption{maxigobot.WithReplyTo(c.message.Body.MID)}, opts...)
+}
+
+func (c *Context) sendPhoto(method string, photo *maxigo.PhotoAttachmentRequestPayload, opts []maxigobot.SendOption) (*maxigo.Message, error) {
+	if photo == nil {
+		return nil, &maxigobot.BotError{Err: maxigobot.ErrNilPhoto}
+	}
+	return c.sendAttachments(method, opts, maxigo.NewPhotoAttachment(*photo))
+}
+
+func (c *Context) sendMedia(method string, opts []maxigobot.SendOption, media ...maxigobot.Media) (*maxigo.Message, error) {
+	opts = append(opts[:len(opts):len(opts)], maxigobot.WithMedia(media...))
+	return c.send(method, "", opts)
+}
+
+func (c *Context) sendAttachments(method string, opts []maxigobot.SendOption, atts ...maxigo.AttachmentRequest) (*maxigo.Message, error) {
+	opts = append(opts[:len(opts):len(opts)], maxigobot.WithAttachments(atts...))
+	return c.send(method, "", opts)
+}
+
+func stickerAttachment(code string) maxigo.AttachmentRequest {
+	return maxigo.NewStickerAttachment(maxigo.StickerAttachmentRequestPayload{Code: code})
+}
+
+func contactAttachment(name, phone string) maxigo.AttachmentRequest {
+	return maxigo.NewContactAttachment(maxigo.ContactAttachmentRequestPayload{
+		Name:     maxigo.Some(name),
+		VCFPhone: maxigo.Some(phone),
+	})
+}
+
+func (c *Context) respond(method, text string) error {
+	if c.callback == nil {
+		return &maxigobot.BotError{Err: maxigobot.ErrNoCallback}
+	}
+	return c.record(Call{Method: method, Text: text})
+}
+
+// attachmentsOf returns the attachments of type T.
+func attachmentsOf[T maxigo.Attachment](atts []maxigo.Attachment) []T {
+	var out []T
+	for _, a := range atts {
+		if t, ok := a.(T); ok {
+			out = append(out, t)
+		}
+	}
+	return out
+}
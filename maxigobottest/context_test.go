@@ -0,0 +1,212 @@
+package maxigobottest
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+	"github.com/maxigo-bot/maxigo-bot/middleware"
+)
+
+func TestContext_recordsSends(t *testing.T) {
+	c := NewContext().WithChat(100).WithSender(1).WithText("/start:ref42")
+
+	if c.Command() != "start" || c.Payload() != "ref42" {
+		t.Errorf("command, payload = %q, %q", c.Command(), c.Payload())
+	}
+	if c.Sender().UserID != 1 {
+		t.Errorf("sender = %+v", c.Sender())
+	}
+
+	handler := func(c maxigobot.Context) error {
+		if err := c.Send("Hi", maxigobot.WithFormat(maxigo.FormatMarkdown)); err != nil {
+			return err
+		}
+		msg, err := c.SendMessage("Working...")
+		if err != nil {
+			return err
+		}
+		if err := c.EditMessage(msg.Body.MID, "Done", maxigobot.WithKeyboard(
+			[]maxigo.Button{maxigo.NewCallbackButton("OK", "ok")},
+		)); err != nil {
+			return err
+		}
+		if err := c.Reply("Reply"); err != nil {
+			return err
+		}
+		if err := c.SendSticker("smile"); err != nil {
+			return err
+		}
+		return c.Notify(maxigo.ActionTypingOn)
+	}
+	if err := handler(c); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := c.Calls()
+	methods := make([]string, len(calls))
+	for i, call := range calls {
+		methods[i] = call.Method
+	}
+	want := []string{"Send", "SendMessage", "EditMessage", "Reply", "SendSticker", "Notify"}
+	if !slices.Equal(methods, want) {
+		t.Fatalf("methods = %v, want %v", methods, want)
+	}
+
+	if f := calls[0].Config.Format; f == nil || *f != maxigo.FormatMarkdown {
+		t.Errorf("Send format = %v", f)
+	}
+	if calls[2].MID != calls[1].MID || calls[2].Text != "Done" || len(calls[2].Config.Attachments) != 1 {
+		t.Errorf("EditMessage = %+v, sent MID %q", calls[2], calls[1].MID)
+	}
+	if calls[3].Config.ReplyTo != c.Message().Body.MID {
+		t.Errorf("ReplyTo = %q, want %q", calls[3].Config.ReplyTo, c.Message().Body.MID)
+	}
+	if atts := calls[4].Config.Attachments; len(atts) != 1 || atts[0].Type != "sticker" {
+		t.Errorf("sticker attachments = %+v", atts)
+	}
+	if calls[5].Action != maxigo.ActionTypingOn {
+		t.Errorf("action = %q", calls[5].Action)
+	}
+
+	if got := c.CallsTo("Send"); len(got) != 1 || got[0].Text != "Hi" {
+		t.Errorf("CallsTo(Send) = %+v", got)
+	}
+	c.Reset()
+	if len(c.Calls()) != 0 {
+		t.Error("calls not reset")
+	}
+}
+
+func TestContext_media(t *testing.T) {
+	c := NewContext().WithChat(100)
+
+	if err := c.SendVideo(maxigobot.File{Token: "v"}, maxigobot.WithDeleteAfter(5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendPhoto(nil); !errors.Is(err, maxigobot.ErrNilPhoto) {
+		t.Errorf("SendPhoto(nil) error = %v", err)
+	}
+
+	call := c.CallsTo("SendVideo")[0]
+	if len(call.Config.Media) != 1 || call.Config.Media[0].Type != maxigo.UploadVideo {
+		t.Errorf("media = %+v", call.Config.Media)
+	}
+	if call.Config.DeleteAfter != 5 {
+		t.Errorf("DeleteAfter = %v", call.Config.DeleteAfter)
+	}
+
+	msg, err := c.SendPhotoMessage(&maxigo.PhotoAttachmentRequestPayload{Token: maxigo.Some("p")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := c.CallsTo("SendPhotoMessage"); len(calls) != 1 || calls[0].MID != msg.Body.MID {
+		t.Errorf("SendPhotoMessage calls = %+v, returned MID %q", calls, msg.Body.MID)
+	}
+}
+
+func TestContext_callback(t *testing.T) {
+	c := NewContext().WithChat(100).WithSender(7).WithCallback("buy:42", "42")
+
+	if c.Data() != "buy:42" || !slices.Equal(c.CallbackArgs(), []string{"42"}) {
+		t.Errorf("data, args = %q, %v", c.Data(), c.CallbackArgs())
+	}
+	if c.Callback().User.UserID != 7 {
+		t.Errorf("callback user = %d", c.Callback().User.UserID)
+	}
+	if err := c.RespondAlert("Sold out"); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.CallsTo("RespondAlert"); len(got) != 1 || got[0].Text != "Sold out" {
+		t.Errorf("RespondAlert calls = %+v", got)
+	}
+}
+
+func TestContext_preconditions(t *testing.T) {
+	c := NewContext()
+
+	if err := c.Send("x"); !errors.Is(err, maxigobot.ErrNoChatID) {
+		t.Errorf("Send error = %v, want ErrNoChatID", err)
+	}
+	if err := c.Edit("x"); !errors.Is(err, maxigobot.ErrNoMessage) {
+		t.Errorf("Edit error = %v, want ErrNoMessage", err)
+	}
+	if err := c.Delete(); !errors.Is(err, maxigobot.ErrNoMessage) {
+		t.Errorf("Delete error = %v, want ErrNoMessage", err)
+	}
+	if err := c.Respond("x"); !errors.Is(err, maxigobot.ErrNoCallback) {
+		t.Errorf("Respond error = %v, want ErrNoCallback", err)
+	}
+	if err := c.SetState("x"); !errors.Is(err, maxigobot.ErrNoStateStorage) {
+		t.Errorf("SetState error = %v, want ErrNoStateStorage", err)
+	}
+	if len(c.Calls()) != 0 {
+		t.Errorf("calls = %+v, want none", c.Calls())
+	}
+}
+
+func TestContext_Fail(t *testing.T) {
+	errSend := errors.New("boom")
+	c := NewContext().WithChat(100).Fail("Send", errSend)
+
+	if err := c.Send("x"); !errors.Is(err, errSend) {
+		t.Errorf("Send error = %v, want %v", err, errSend)
+	}
+	if len(c.CallsTo("Send")) != 1 {
+		t.Error("failed call not recorded")
+	}
+	c.Fail("Send", nil)
+	if err := c.Send("x"); err != nil {
+		t.Errorf("Send error = %v after removing the failure", err)
+	}
+}
+
+func TestContext_storeStateAndAttachments(t *testing.T) {
+	c := NewContext().WithState("menu").WithDownload([]byte("data")).WithVerifiedContact()
+	c.WithAttachments(
+		&maxigo.PhotoAttachment{},
+		&maxigo.ContactAttachment{},
+		&maxigo.LocationAttachment{Latitude: 1, Longitude: 2},
+	)
+
+	c.Set("user", "alice")
+	if c.Get("user") != "alice" {
+		t.Errorf("Get = %v", c.Get("user"))
+	}
+	if c.State() != "menu" {
+		t.Errorf("State = %q", c.State())
+	}
+	if err := c.SetState("done"); err != nil || c.State() != "done" {
+		t.Errorf("SetState: err = %v, state = %q", err, c.State())
+	}
+
+	if len(c.Photos()) != 1 || len(c.Files()) != 0 || c.Location().Latitude != 1 {
+		t.Errorf("photos, files, location = %v, %v, %v", c.Photos(), c.Files(), c.Location())
+	}
+	if contact, verified := c.Contact(); contact == nil || !verified {
+		t.Errorf("Contact = %v, %v", contact, verified)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Download(c.Photos()[0], &buf); err != nil || buf.String() != "data" {
+		t.Errorf("Download: err = %v, data = %q", err, buf.String())
+	}
+}
+
+func TestContext_middleware(t *testing.T) {
+	c := NewContext().WithChat(100).WithText("hi")
+
+	h := middleware.DeleteTrigger()(func(c maxigobot.Context) error {
+		return c.Send("ok")
+	})
+	if err := h(c); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.CallsTo("Delete"); len(got) != 1 || got[0].MID != c.Message().Body.MID {
+		t.Errorf("Delete calls = %+v", got)
+	}
+}
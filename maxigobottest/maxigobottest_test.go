@@ -0,0 +1,226 @@
+package maxigobottest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+func TestConversation(t *testing.T) {
+	srv := NewServer(t)
+	b, p := srv.NewBot(t)
+
+	b.Handle(maxigobot.OnBotStarted, func(c maxigobot.Context) error {
+		return c.Send("Welcome, ref " + c.Payload())
+	})
+	b.Handle("/menu", func(c maxigobot.Context) error {
+		return c.Send("Menu", maxigobot.WithKeyboard(
+			[]maxigo.Button{maxigo.NewCallbackButton("Buy", "buy:42")},
+		))
+	})
+	b.Handle(maxigobot.OnCallback("buy"), func(c maxigobot.Context) error {
+		if err := c.Respond("Bought " + c.CallbackArgs()[0]); err != nil {
+			return err
+		}
+		return c.Edit("Thanks!")
+	})
+	Start(t, b)
+
+	p.Send(NewBotStartedUpdate(100, 7, "promo"))
+	srv.AssertSentTo(t, 0, 100, "Welcome, ref promo")
+
+	p.Send(NewTextUpdate(100, 7, "/menu"))
+	sent := srv.WaitSent(t, 2)
+	if att := sent[1].Body.Attachments; len(att) != 1 || att[0].Type != "inline_keyboard" {
+		t.Errorf("menu attachments = %+v", att)
+	}
+
+	cb := NewCallbackUpdate(100, 7, "buy:42")
+	p.Send(cb)
+	srv.AssertAnswered(t, "Bought 42")
+	edits := srv.WaitEdits(t, 1)
+	if edits[0].MID != cb.Message.Body.MID || edits[0].Text != "Thanks!" {
+		t.Errorf("edit = %+v", edits[0])
+	}
+	if srv.Answers()[0].CallbackID != cb.Callback.CallbackID {
+		t.Errorf("answered callback %q, want %q", srv.Answers()[0].CallbackID, cb.Callback.CallbackID)
+	}
+
+	srv.AssertSent(t, "Welcome, ref promo", "Menu")
+}
+
+func TestServer_recordsUploadsDeletesAndActions(t *testing.T) {
+	srv := NewServer(t)
+	b, p := srv.NewBot(t)
+	b.Handle(maxigobot.OnText, func(c maxigobot.Context) error {
+		if err := c.Notify(maxigo.ActionTypingOn); err != nil {
+			return err
+		}
+		msg, err := c.SendMessage("", maxigobot.WithMedia(
+			maxigobot.Photo(maxigobot.FileFromReader("cat.jpg", strings.NewReader("meow"))),
+			maxigobot.Document(maxigobot.FileFromReader("cv.pdf", strings.NewReader("pdf"))),
+		))
+		if err != nil {
+			return err
+		}
+		return c.DeleteMessage(msg.Body.MID)
+	})
+	Start(t, b)
+
+	p.Send(NewTextUpdate(5, 6, "hi"))
+	p.Wait(t)
+
+	uploads := srv.Uploads()
+	if len(uploads) != 2 || uploads[0].Type != maxigo.UploadImage || uploads[0].Filename != "cat.jpg" ||
+		string(uploads[0].Data) != "meow" || uploads[1].Type != maxigo.UploadFile {
+		t.Errorf("uploads = %+v", uploads)
+	}
+	sent := srv.Sent()
+	if len(sent) != 1 || len(sent[0].Body.Attachments) != 2 {
+		t.Fatalf("sent = %+v", sent)
+	}
+	if got := srv.Deleted(); len(got) != 1 || got[0] != sent[0].MID {
+		t.Errorf("deleted = %v, want [%s]", got, sent[0].MID)
+	}
+	if got := srv.Actions(); len(got) != 1 || got[0].ChatID != 5 || got[0].Action != maxigo.ActionTypingOn {
+		t.Errorf("actions = %+v", got)
+	}
+
+	srv.Reset()
+	if len(srv.Sent()) != 0 || len(srv.Uploads()) != 0 {
+		t.Error("Reset should clear recorded requests")
+	}
+}
+
+func TestServer_Fail(t *testing.T) {
+	srv := NewServer(t)
+	b, p := srv.NewBot(t)
+
+	var mu sync.Mutex
+	var gotErr error
+	b.OnError = func(err error, c maxigobot.Context) {
+		mu.Lock()
+		gotErr = err
+		mu.Unlock()
+	}
+	b.Handle("/start", func(c maxigobot.Context) error {
+		return c.Send("hello")
+	})
+	srv.Fail("POST /messages", http.StatusForbidden, "chat.denied", "bot is blocked")
+	Start(t, b)
+
+	p.Send(NewTextUpdate(1, 2, "/start"))
+	p.Wait(t)
+
+	mu.Lock()
+	defer mu.Unlock()
+	var apiErr *maxigo.Error
+	if !errors.As(gotErr, &apiErr) || apiErr.StatusCode != http.StatusForbidden || !maxigobot.IsUnreachable(gotErr) {
+		t.Errorf("error = %v, want 403 API error", gotErr)
+	}
+	if len(srv.Sent()) != 0 {
+		t.Error("failed requests should not be recorded")
+	}
+
+	srv.Handle("POST /messages", nil)
+	p.Send(NewTextUpdate(1, 2, "/start"))
+	srv.AssertSent(t, "hello")
+}
+
+func TestServer_Fail_rateLimitNotRetried(t *testing.T) {
+	srv := NewServer(t)
+	b, p := srv.NewBot(t)
+
+	var mu sync.Mutex
+	var gotErr error
+	b.OnError = func(err error, c maxigobot.Context) {
+		mu.Lock()
+		gotErr = err
+		mu.Unlock()
+	}
+	b.Handle("/start", func(c maxigobot.Context) error {
+		return c.Send("hello")
+	})
+	srv.Fail("POST /messages", http.StatusTooManyRequests, "too.many.requests", "slow down")
+	Start(t, b)
+
+	start := time.Now()
+	p.Send(NewTextUpdate(1, 2, "/start"))
+	p.Wait(t)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("429 took %v to surface, want no retries", elapsed)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var apiErr *maxigo.Error
+	if !errors.As(gotErr, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("error = %v, want 429 API error", gotErr)
+	}
+}
+
+func TestNewUpdates(t *testing.T) {
+	text := NewTextUpdate(10, 20, "hi")
+	if text.UpdateType != maxigo.UpdateMessageCreated || *text.Message.Recipient.ChatID != 10 ||
+		text.Message.Sender.UserID != 20 || *text.Message.Body.Text != "hi" || text.Message.Body.MID == "" {
+		t.Errorf("text update = %+v", text)
+	}
+
+	cb1, cb2 := NewCallbackUpdate(10, 20, "p"), NewCallbackUpdate(10, 20, "p")
+	if cb1.Callback.CallbackID == cb2.Callback.CallbackID {
+		t.Error("callback IDs should be unique")
+	}
+	if cb1.Callback.User.UserID != 20 || *cb1.Message.Recipient.ChatID != 10 {
+		t.Errorf("callback update = %+v", cb1)
+	}
+
+	if started := NewBotStartedUpdate(10, 20, ""); started.Payload != nil || started.ChatID != 10 {
+		t.Errorf("bot started update = %+v", started)
+	}
+}
+
+func TestReplayAgainstServer(t *testing.T) {
+	var buf bytes.Buffer
+	rec := maxigobot.NewUpdateRecorder(&buf)
+	for _, text := range []string{"/start", "/help"} {
+		raw, err := json.Marshal(NewTextUpdate(10, 20, text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.Record(raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv := NewServer(t)
+	replay := &maxigobot.ReplayPoller{Reader: &buf, Speed: -1}
+	b, _ := srv.NewBot(t, maxigobot.WithPoller(replay))
+	b.Handle(maxigobot.OnText, func(c maxigobot.Context) error {
+		return c.Send("echo " + c.Text())
+	})
+	b.Handle("/start", func(c maxigobot.Context) error {
+		return c.Send("started")
+	})
+	Start(t, b)
+
+	<-replay.Done()
+	// Updates are handled concurrently, so the order of replies may vary.
+	var texts []string
+	for _, m := range srv.WaitSent(t, 2) {
+		texts = append(texts, m.Text)
+	}
+	slices.Sort(texts)
+	if !slices.Equal(texts, []string{"echo /help", "started"}) {
+		t.Errorf("sent texts = %q", texts)
+	}
+}
@@ -0,0 +1,94 @@
+package maxigobottest
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// Poller is a maxigobot.Poller fed by the test through Send.
+type Poller struct {
+	ch   chan any
+	sent atomic.Int64
+	done atomic.Int64
+}
+
+// NewPoller creates a Poller. Bots created with Server.NewBot already have one.
+func NewPoller() *Poller {
+	return &Poller{ch: make(chan any, 64)}
+}
+
+// Send delivers an update to the bot. It does not wait for the update to
+// be handled; use Wait or the Server's Wait and Assert helpers.
+func (p *Poller) Send(update any) {
+	p.sent.Add(1)
+	p.ch <- update
+}
+
+// Poll implements maxigobot.Poller.
+func (p *Poller) Poll(_ *maxigobot.Bot, updates chan<- any, stop chan struct{}) {
+	defer close(updates)
+	for {
+		select {
+		case <-stop:
+			return
+		case u := <-p.ch:
+			select {
+			case updates <- u:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+// Wait blocks until every update passed to Send has been handled, or fails
+// the test after DefaultTimeout. It requires a bot created with
+// Server.NewBot, and updates that are routed (e.g. built with NewTextUpdate).
+func (p *Poller) Wait(tb testing.TB) {
+	tb.Helper()
+	waitUntil(tb, DefaultTimeout, "updates to be handled", func() bool {
+		return p.done.Load() >= p.sent.Load()
+	})
+}
+
+// track is a pre-middleware counting handled updates for Wait.
+func (p *Poller) track(next maxigobot.HandlerFunc) maxigobot.HandlerFunc {
+	return func(c maxigobot.Context) error {
+		defer p.done.Add(1)
+		return next(c)
+	}
+}
+
+// Start runs b.Start in the background and stops the bot when the test ends,
+// failing the test if Start does not return within DefaultTimeout.
+func Start(tb testing.TB, b *maxigobot.Bot) {
+	tb.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Start()
+	}()
+	tb.Cleanup(func() {
+		b.Stop()
+		select {
+		case <-done:
+		case <-time.After(DefaultTimeout):
+			tb.Error("maxigobottest: bot did not stop")
+		}
+	})
+}
+
+// waitUntil polls cond until it holds or timeout expires, failing the test.
+func waitUntil(tb testing.TB, timeout time.Duration, what string, cond func() bool) {
+	tb.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			tb.Fatalf("maxigobottest: timed out after %v waiting for %s", timeout, what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
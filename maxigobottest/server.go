@@ -0,0 +1,382 @@
+// Package maxigobottest provides utilities for testing bots built with
+// maxigobot: a fake Max Bot API server that records what the bot sends,
+// update builders, a channel-driven Poller, assertion helpers and a
+// Context test double for unit-testing handlers and middleware.
+//
+// A whole conversation runs through Bot.Start:
+//
+//	func TestStart(t *testing.T) {
+//		srv := maxigobottest.NewServer(t)
+//		b, p := srv.NewBot(t)
+//		b.Handle("/start", func(c maxigobot.Context) error {
+//			return c.Send("Hello!")
+//		})
+//		maxigobottest.Start(t, b)
+//
+//		p.Send(maxigobottest.NewTextUpdate(100, 1, "/start"))
+//		srv.AssertSent(t, "Hello!")
+//	}
+package maxigobottest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// DefaultTimeout is the default time the Wait and Assert helpers wait for
+// the bot to make the expected requests.
+const DefaultTimeout = 2 * time.Second
+
+// SentMessage is a message sent through POST /messages.
+type SentMessage struct {
+	// ChatID is the target chat (0 if sent to a user).
+	ChatID int64
+	// UserID is the target user (0 if sent to a chat).
+	UserID int64
+	// MID is the message ID assigned by the server.
+	MID string
+	// Text is the message text.
+	Text string
+	// Body is the decoded request body.
+	Body maxigo.NewMessageBody
+}
+
+// EditedMessage is a message edit made through PUT /messages.
+type EditedMessage struct {
+	MID  string
+	Text string
+	Body maxigo.NewMessageBody
+}
+
+// CallbackAnswer is an answer to a callback made through POST /answers.
+type CallbackAnswer struct {
+	CallbackID   string
+	Notification string
+	// Message is the new message body, if the answer replaced the message.
+	Message *maxigo.NewMessageBody
+}
+
+// Upload is a file uploaded to the server.
+type Upload struct {
+	Type     maxigo.UploadType
+	Filename string
+	Data     []byte
+	// Token is the token the server returned for the file.
+	Token string
+}
+
+// Action is a chat action such as typing_on sent through POST /chats/{id}/actions.
+type Action struct {
+	ChatID int64
+	Action maxigo.SenderAction
+}
+
+// Server is a fake Max Bot API. It answers the requests a bot makes when
+// handling updates and records them for assertions. Individual endpoints
+// can be scripted with Handle and Fail. It is safe for concurrent use.
+type Server struct {
+	// URL is the base URL of the server.
+	URL string
+	// Timeout bounds the Wait and Assert helpers. Default: DefaultTimeout.
+	Timeout time.Duration
+
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	seq       int
+	overrides map[string]http.HandlerFunc
+	sent      []SentMessage
+	edits     []EditedMessage
+	deleted   []string
+	answers   []CallbackAnswer
+	uploads   []Upload
+	actions   []Action
+}
+
+// NewServer starts a fake API server that is closed when the test ends.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+	s := &Server{overrides: make(map[string]http.HandlerFunc)}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	tb.Cleanup(s.srv.Close)
+	return s
+}
+
+// Client returns a maxigo-client that talks to the server.
+func (s *Server) Client() *maxigo.Client {
+	c, err := maxigo.New("test-token", maxigo.WithBaseURL(s.URL))
+	if err != nil {
+		panic(err) // Only fails for an empty token.
+	}
+	return c
+}
+
+// uploadRetryIntervals is the retry schedule for "file not processed" errors
+// of bots created by NewBot: the default schedule scaled down so that tests
+// do not sleep.
+var uploadRetryIntervals = []time.Duration{
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+}
+
+// NewBot creates a bot connected to the server and a Poller feeding it.
+// Rate limit (HTTP 429) errors are not retried and unprocessed uploads are
+// retried within milliseconds, so scripted failures (see Fail) surface
+// immediately. opts are applied after these defaults and the client and
+// poller options, e.g. pass maxigobot.WithRateLimitIntervals to test
+// retries. The bot is not started; register handlers, then call Start.
+func (s *Server) NewBot(tb testing.TB, opts ...maxigobot.Option) (*maxigobot.Bot, *Poller) {
+	tb.Helper()
+	p := NewPoller()
+	opts = append([]maxigobot.Option{
+		maxigobot.WithClient(s.Client()),
+		maxigobot.WithPoller(p),
+		maxigobot.WithRateLimitIntervals(),
+		maxigobot.WithUploadRetryIntervals(uploadRetryIntervals...),
+	}, opts...)
+	b, err := maxigobot.New("test-token", opts...)
+	if err != nil {
+		tb.Fatalf("maxigobottest: create bot: %v", err)
+	}
+	b.Pre(p.track)
+	return b, p
+}
+
+// Handle overrides the response to requests matching pattern, given as
+// "METHOD /path" (e.g. "POST /messages"). Overridden requests are not
+// recorded. A nil handler removes the override.
+func (s *Server) Handle(pattern string, h http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if h == nil {
+		delete(s.overrides, pattern)
+		return
+	}
+	s.overrides[pattern] = h
+}
+
+// Fail makes requests matching pattern (see Handle) fail with an API error.
+func (s *Server) Fail(pattern string, status int, code, message string) {
+	s.Handle(pattern, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, status, map[string]string{"code": code, "message": message})
+	})
+}
+
+// Sent returns the messages sent so far.
+func (s *Server) Sent() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentMessage(nil), s.sent...)
+}
+
+// Edits returns the message edits made so far.
+func (s *Server) Edits() []EditedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]EditedMessage(nil), s.edits...)
+}
+
+// Deleted returns the IDs of the messages deleted so far.
+func (s *Server) Deleted() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.deleted...)
+}
+
+// Answers returns the callback answers made so far.
+func (s *Server) Answers() []CallbackAnswer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CallbackAnswer(nil), s.answers...)
+}
+
+// Uploads returns the files uploaded so far.
+func (s *Server) Uploads() []Upload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Upload(nil), s.uploads...)
+}
+
+// Actions returns the chat actions sent so far.
+func (s *Server) Actions() []Action {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Action(nil), s.actions...)
+}
+
+// Reset clears all recorded requests. Overrides are kept.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent, s.edits, s.deleted = nil, nil, nil
+	s.answers, s.uploads, s.actions = nil, nil, nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	override := s.overrides[r.Method+" "+r.URL.Path]
+	s.mu.Unlock()
+	if override != nil {
+		override(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	switch {
+	case r.URL.Path == "/messages" && r.Method == http.MethodPost:
+		var body maxigo.NewMessageBody
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		msg := SentMessage{
+			ChatID: queryInt(q, "chat_id"),
+			UserID: queryInt(q, "user_id"),
+			Text:   body.Text.Value,
+			Body:   body,
+		}
+		s.mu.Lock()
+		s.seq++
+		msg.MID = "mid." + strconv.Itoa(s.seq)
+		s.sent = append(s.sent, msg)
+		s.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]any{"message": s.message(msg)})
+
+	case r.URL.Path == "/messages" && r.Method == http.MethodPut:
+		var body maxigo.NewMessageBody
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		s.mu.Lock()
+		s.edits = append(s.edits, EditedMessage{MID: q.Get("message_id"), Text: body.Text.Value, Body: body})
+		s.mu.Unlock()
+		writeSuccess(w)
+
+	case r.URL.Path == "/messages" && r.Method == http.MethodDelete:
+		s.mu.Lock()
+		s.deleted = append(s.deleted, q.Get("message_id"))
+		s.mu.Unlock()
+		writeSuccess(w)
+
+	case r.URL.Path == "/answers" && r.Method == http.MethodPost:
+		var body maxigo.CallbackAnswer
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		s.mu.Lock()
+		s.answers = append(s.answers, CallbackAnswer{
+			CallbackID:   q.Get("callback_id"),
+			Notification: body.Notification.Value,
+			Message:      body.Message,
+		})
+		s.mu.Unlock()
+		writeSuccess(w)
+
+	case r.URL.Path == "/uploads" && r.Method == http.MethodPost:
+		writeJSON(w, http.StatusOK, map[string]string{"url": s.URL + "/upload/" + q.Get("type")})
+
+	case strings.HasPrefix(r.URL.Path, "/upload/") && r.Method == http.MethodPost:
+		s.upload(w, r, maxigo.UploadType(strings.TrimPrefix(r.URL.Path, "/upload/")))
+
+	case strings.HasPrefix(r.URL.Path, "/chats/") && strings.HasSuffix(r.URL.Path, "/actions"):
+		var body maxigo.ActionRequestBody
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/chats/"), "/actions")
+		chatID, _ := strconv.ParseInt(id, 10, 64)
+		s.mu.Lock()
+		s.actions = append(s.actions, Action{ChatID: chatID, Action: body.Action})
+		s.mu.Unlock()
+		writeSuccess(w)
+
+	case r.URL.Path == "/me" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, maxigo.User{UserID: 1, FirstName: "Test Bot", IsBot: true})
+
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{
+			"code":    "not.found",
+			"message": "maxigobottest: no fake for " + r.Method + " " + r.URL.Path,
+		})
+	}
+}
+
+// upload stores a multipart upload and returns a token for it.
+func (s *Server) upload(w http.ResponseWriter, r *http.Request, typ maxigo.UploadType) {
+	f, hdr, err := r.FormFile("data")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"code": "bad.request", "message": err.Error()})
+		return
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"code": "bad.request", "message": err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	s.seq++
+	token := fmt.Sprintf("%s-token-%d", typ, s.seq)
+	s.uploads = append(s.uploads, Upload{Type: typ, Filename: hdr.Filename, Data: data, Token: token})
+	s.mu.Unlock()
+
+	if typ == maxigo.UploadImage {
+		writeJSON(w, http.StatusOK, maxigo.PhotoTokens{Photos: map[string]maxigo.PhotoToken{"photo": {Token: token}}})
+		return
+	}
+	writeJSON(w, http.StatusOK, maxigo.UploadedInfo{Token: token})
+}
+
+// message returns the API representation of a sent message.
+func (s *Server) message(m SentMessage) maxigo.Message {
+	msg := maxigo.Message{
+		Sender:    &maxigo.User{UserID: 1, FirstName: "Test Bot", IsBot: true},
+		Timestamp: time.Now().UnixMilli(),
+		Body:      maxigo.MessageBody{MID: m.MID, Text: &m.Text},
+	}
+	if m.ChatID != 0 {
+		msg.Recipient = maxigo.Recipient{ChatID: &m.ChatID, ChatType: maxigo.ChatDialog}
+	} else {
+		msg.Recipient = maxigo.Recipient{UserID: &m.UserID, ChatType: maxigo.ChatDialog}
+	}
+	return msg
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"code": "bad.request", "message": err.Error()})
+		return false
+	}
+	return true
+}
+
+func queryInt(q url.Values, key string) int64 {
+	n, _ := strconv.ParseInt(q.Get(key), 10, 64)
+	return n
+}
+
+func writeSuccess(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, maxigo.SimpleQueryResult{Success: true})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
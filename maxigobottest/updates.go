@@ -0,0 +1,73 @@
+package maxigobottest
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// ids generates unique message and callback IDs for built updates.
+var ids atomic.Int64
+
+func nextID(prefix string) string {
+	return prefix + strconv.FormatInt(ids.Add(1), 10)
+}
+
+func newUpdate(typ maxigo.UpdateType) maxigo.Update {
+	return maxigo.Update{UpdateType: typ, Timestamp: time.Now().UnixMilli()}
+}
+
+func newUser(userID int64) maxigo.User {
+	return maxigo.User{UserID: userID, FirstName: "User " + strconv.FormatInt(userID, 10)}
+}
+
+// NewTextUpdate builds a message_created update with a text message from
+// userID in the dialog chatID.
+func NewTextUpdate(chatID, userID int64, text string) *maxigo.MessageCreatedUpdate {
+	sender := newUser(userID)
+	return &maxigo.MessageCreatedUpdate{
+		Update: newUpdate(maxigo.UpdateMessageCreated),
+		Message: maxigo.Message{
+			Sender:    &sender,
+			Recipient: maxigo.Recipient{ChatID: &chatID, ChatType: maxigo.ChatDialog},
+			Timestamp: time.Now().UnixMilli(),
+			Body:      maxigo.MessageBody{MID: nextID("user.mid."), Text: &text},
+		},
+	}
+}
+
+// NewCallbackUpdate builds a message_callback update for userID pressing a
+// button with payload on a bot message in chatID.
+func NewCallbackUpdate(chatID, userID int64, payload string) *maxigo.MessageCallbackUpdate {
+	return &maxigo.MessageCallbackUpdate{
+		Update: newUpdate(maxigo.UpdateMessageCallback),
+		Callback: maxigo.Callback{
+			Timestamp:  time.Now().UnixMilli(),
+			CallbackID: nextID("callback."),
+			Payload:    payload,
+			User:       newUser(userID),
+		},
+		Message: &maxigo.Message{
+			Sender:    &maxigo.User{UserID: 1, FirstName: "Test Bot", IsBot: true},
+			Recipient: maxigo.Recipient{ChatID: &chatID, ChatType: maxigo.ChatDialog},
+			Timestamp: time.Now().UnixMilli(),
+			Body:      maxigo.MessageBody{MID: nextID("bot.mid.")},
+		},
+	}
+}
+
+// NewBotStartedUpdate builds a bot_started update for userID pressing
+// Start in chatID, with an optional deep-link payload ("" for none).
+func NewBotStartedUpdate(chatID, userID int64, payload string) *maxigo.BotStartedUpdate {
+	u := &maxigo.BotStartedUpdate{
+		Update: newUpdate(maxigo.UpdateBotStarted),
+		ChatID: chatID,
+		User:   newUser(userID),
+	}
+	if payload != "" {
+		u.Payload = &payload
+	}
+	return u
+}
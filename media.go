@@ -0,0 +1,339 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// ErrEmptyFile is returned when a File has no source to send.
+var ErrEmptyFile = errors.New("maxigobot: file has no token, path, reader or URL")
+
+// File is a media source for sending. Exactly one source should be set;
+// use the FileFrom* constructors.
+type File struct {
+	// Token is the token of an already uploaded file.
+	Token string
+	// Path is a local file path to upload.
+	Path string
+	// Reader is the content to upload, named Name.
+	Reader io.Reader
+	// Name is the file name used when uploading from Reader.
+	Name string
+	// URL is a remote http(s) file. Images are passed to Max by URL;
+	// other media are downloaded and uploaded by the bot.
+	//
+	// Security: do not pass untrusted user input as URL without
+	// validation, as it allows requests to internal networks.
+	URL string
+	// Key identifies the content in the FileCache. Files from Path and URL
+	// are cached automatically; set Key to cache Reader uploads too.
+	Key string
+}
+
+// FileFromToken returns a File referring to an already uploaded file.
+func FileFromToken(token string) File { return File{Token: token} }
+
+// FileFromPath returns a File uploaded from a local path.
+func FileFromPath(path string) File { return File{Path: path} }
+
+// FileFromReader returns a File uploaded from r with the given file name.
+func FileFromReader(name string, r io.Reader) File { return File{Name: name, Reader: r} }
+
+// FileFromURL returns a File fetched from an http(s) URL.
+func FileFromURL(url string) File { return File{URL: url} }
+
+// Media is a File with the kind of attachment it is sent as.
+type Media struct {
+	// Type is the upload type: maxigo.UploadImage, UploadVideo, UploadAudio or UploadFile.
+	Type maxigo.UploadType
+	File File
+}
+
+// Photo returns f as an image attachment.
+func Photo(f File) Media { return Media{Type: maxigo.UploadImage, File: f} }
+
+// Video returns f as a video attachment.
+func Video(f File) Media { return Media{Type: maxigo.UploadVideo, File: f} }
+
+// Audio returns f as an audio attachment.
+func Audio(f File) Media { return Media{Type: maxigo.UploadAudio, File: f} }
+
+// Document returns f as a file attachment.
+func Document(f File) Media { return Media{Type: maxigo.UploadFile, File: f} }
+
+// FileCache stores upload tokens so that sending the same file again does
+// not upload it again. Implementations must be safe for concurrent use.
+type FileCache interface {
+	// GetToken returns the token cached for key, or "" if none.
+	GetToken(ctx gocontext.Context, key string) (string, error)
+	// SetToken caches the token for key.
+	SetToken(ctx gocontext.Context, key, token string) error
+}
+
+// WithFileCache sets the cache for upload tokens.
+// Default: an in-memory cache (see NewMemoryFileCache).
+// Pass nil to upload files on every send.
+func WithFileCache(c FileCache) Option {
+	return func(b *Bot) {
+		b.fileCache = c
+	}
+}
+
+// MemoryFileCache is an in-memory FileCache.
+type MemoryFileCache struct {
+	mu     sync.RWMutex
+	tokens map[string]string
+}
+
+// NewMemoryFileCache creates an empty in-memory FileCache.
+func NewMemoryFileCache() *MemoryFileCache {
+	return &MemoryFileCache{tokens: make(map[string]string)}
+}
+
+// GetToken implements FileCache.
+func (m *MemoryFileCache) GetToken(_ gocontext.Context, key string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.tokens[key], nil
+}
+
+// SetToken implements FileCache.
+func (m *MemoryFileCache) SetToken(_ gocontext.Context, key, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens[key] = token
+	return nil
+}
+
+// cacheKey returns the FileCache key of m, or "" if it cannot be cached.
+// Local files are keyed by path, size and modification time, so a changed
+// file is uploaded again.
+func cacheKey(m Media) string {
+	f := m.File
+	switch {
+	case f.Key != "":
+		return string(m.Type) + ":key:" + f.Key
+	case f.Path != "":
+		abs, err := filepath.Abs(f.Path)
+		if err != nil {
+			return ""
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("%s:path:%s:%d:%d", m.Type, abs, info.Size(), info.ModTime().UnixNano())
+	case f.URL != "" && m.Type != maxigo.UploadImage: // Images are sent by URL, not uploaded.
+		return string(m.Type) + ":url:" + f.URL
+	}
+	return ""
+}
+
+// upload uploads m if needed and returns the attachment to send.
+// Tokens of uploaded files are stored in the bot's FileCache and reused.
+// Upload requests go through the rate limiter but are not retried,
+// since a Reader cannot be read twice.
+func (b *Bot) upload(ctx gocontext.Context, chatID int64, m Media) (maxigo.AttachmentRequest, error) {
+	f := m.File
+	if f.Token == "" && f.Path == "" && f.Reader == nil && f.URL == "" {
+		return maxigo.AttachmentRequest{}, &BotError{Err: ErrEmptyFile}
+	}
+
+	var key string
+	if f.Token == "" && b.fileCache != nil {
+		key = cacheKey(m)
+	}
+	if key != "" {
+		token, err := b.fileCache.GetToken(ctx, key)
+		if err != nil {
+			b.handleError(fmt.Errorf("file cache: %w", err), nil, "upload")
+		}
+		if token != "" {
+			att, _, err := b.uploadFile(ctx, chatID, Media{Type: m.Type, File: FileFromToken(token)})
+			return att, err
+		}
+	}
+
+	att, token, err := b.uploadFile(ctx, chatID, m)
+	if err == nil && key != "" && token != "" {
+		if err := b.fileCache.SetToken(ctx, key, token); err != nil {
+			b.handleError(fmt.Errorf("file cache: %w", err), nil, "upload")
+		}
+	}
+	return att, err
+}
+
+// uploadFile uploads m if needed and returns the attachment to send
+// along with the token of the uploaded file ("" if nothing was uploaded).
+func (b *Bot) uploadFile(ctx gocontext.Context, chatID int64, m Media) (maxigo.AttachmentRequest, string, error) {
+	f := m.File
+	if f.Token == "" && (m.Type != maxigo.UploadImage || f.URL == "") {
+		if err := b.limit.wait(ctx, chatID); err != nil {
+			return maxigo.AttachmentRequest{}, "", err
+		}
+	}
+
+	var token string
+	if m.Type == maxigo.UploadImage {
+		var photo maxigo.PhotoAttachmentRequestPayload
+		switch {
+		case f.Token != "":
+			photo.Token = maxigo.Some(f.Token)
+		case f.URL != "":
+			photo.URL = maxigo.Some(f.URL)
+		default:
+			var (
+				tokens *maxigo.PhotoTokens
+				err    error
+			)
+			if f.Path != "" {
+				tokens, err = b.client.UploadPhotoFromFile(ctx, f.Path)
+			} else {
+				tokens, err = b.client.UploadPhoto(ctx, f.Name, f.Reader)
+			}
+			if err != nil {
+				return maxigo.AttachmentRequest{}, "", err
+			}
+			photo.Photos = tokens.Photos
+			for _, t := range tokens.Photos {
+				token = t.Token
+				break
+			}
+		}
+		return maxigo.NewPhotoAttachment(photo), token, nil
+	}
+
+	info := maxigo.UploadedInfo{Token: f.Token}
+	if f.Token == "" {
+		var (
+			uploaded *maxigo.UploadedInfo
+			err      error
+		)
+		switch {
+		case f.Path != "":
+			uploaded, err = b.client.UploadMediaFromFile(ctx, m.Type, f.Path)
+		case f.Reader != nil:
+			uploaded, err = b.client.UploadMedia(ctx, m.Type, f.Name, f.Reader)
+		default:
+			uploaded, err = b.client.UploadMediaFromURL(ctx, m.Type, f.URL)
+		}
+		if err != nil {
+			return maxigo.AttachmentRequest{}, "", err
+		}
+		info = *uploaded
+		token = info.Token
+	}
+
+	switch m.Type {
+	case maxigo.UploadVideo:
+		return maxigo.NewVideoAttachment(info), token, nil
+	case maxigo.UploadAudio:
+		return maxigo.NewAudioAttachment(info), token, nil
+	default:
+		return maxigo.NewFileAttachment(info), token, nil
+	}
+}
+
+// uploadMedia uploads the media of cfg and prepends them to its attachments.
+func (b *Bot) uploadMedia(ctx gocontext.Context, chatID int64, cfg *SendConfig) error {
+	if len(cfg.Media) == 0 {
+		return nil
+	}
+	atts := make([]maxigo.AttachmentRequest, 0, len(cfg.Media)+len(cfg.Attachments))
+	for _, m := range cfg.Media {
+		att, err := b.upload(ctx, chatID, m)
+		if err != nil {
+			return err
+		}
+		atts = append(atts, att)
+	}
+	cfg.Attachments = append(atts, cfg.Attachments...)
+	cfg.Media = nil
+	return nil
+}
+
+func (c *nativeContext) SendVideo(f File, opts ...SendOption) error {
+	_, err := c.SendVideoMessage(f, opts...)
+	return err
+}
+
+func (c *nativeContext) SendVideoMessage(f File, opts ...SendOption) (*maxigo.Message, error) {
+	return c.sendMedia([]Media{Video(f)}, opts)
+}
+
+func (c *nativeContext) SendAudio(f File, opts ...SendOption) error {
+	_, err := c.SendAudioMessage(f, opts...)
+	return err
+}
+
+func (c *nativeContext) SendAudioMessage(f File, opts ...SendOption) (*maxigo.Message, error) {
+	return c.sendMedia([]Media{Audio(f)}, opts)
+}
+
+func (c *nativeContext) SendFile(f File, opts ...SendOption) error {
+	_, err := c.SendFileMessage(f, opts...)
+	return err
+}
+
+func (c *nativeContext) SendFileMessage(f File, opts ...SendOption) (*maxigo.Message, error) {
+	return c.sendMedia([]Media{Document(f)}, opts)
+}
+
+func (c *nativeContext) SendAlbum(media []Media, opts ...SendOption) error {
+	_, err := c.SendAlbumMessage(media, opts...)
+	return err
+}
+
+func (c *nativeContext) SendAlbumMessage(media []Media, opts ...SendOption) (*maxigo.Message, error) {
+	return c.sendMedia(media, opts)
+}
+
+func (c *nativeContext) SendSticker(code string, opts ...SendOption) error {
+	_, err := c.SendStickerMessage(code, opts...)
+	return err
+}
+
+func (c *nativeContext) SendStickerMessage(code string, opts ...SendOption) (*maxigo.Message, error) {
+	return c.sendAttachments(opts, maxigo.NewStickerAttachment(maxigo.StickerAttachmentRequestPayload{Code: code}))
+}
+
+func (c *nativeContext) SendLocation(latitude, longitude float64, opts ...SendOption) error {
+	_, err := c.SendLocationMessage(latitude, longitude, opts...)
+	return err
+}
+
+func (c *nativeContext) SendLocationMessage(latitude, longitude float64, opts ...SendOption) (*maxigo.Message, error) {
+	return c.sendAttachments(opts, maxigo.NewLocationAttachment(latitude, longitude))
+}
+
+func (c *nativeContext) SendContact(name, phone string, opts ...SendOption) error {
+	_, err := c.SendContactMessage(name, phone, opts...)
+	return err
+}
+
+func (c *nativeContext) SendContactMessage(name, phone string, opts ...SendOption) (*maxigo.Message, error) {
+	return c.sendAttachments(opts, maxigo.NewContactAttachment(maxigo.ContactAttachmentRequestPayload{
+		Name:     maxigo.Some(name),
+		VCFPhone: maxigo.Some(phone),
+	}))
+}
+
+// sendMedia sends media as a single message without text.
+func (c *nativeContext) sendMedia(media []Media, opts []SendOption) (*maxigo.Message, error) {
+	opts = append(opts[:len(opts):len(opts)], WithMedia(media...))
+	return c.SendMessage("", opts...)
+}
+
+// sendAttachments sends a message without text carrying atts after any
+// attachments from opts.
+func (c *nativeContext) sendAttachments(opts []SendOption, atts ...maxigo.AttachmentRequest) (*maxigo.Message, error) {
+	opts = append(opts[:len(opts):len(opts)], WithAttachments(atts...))
+	return c.SendMessage("", opts...)
+}
@@ -0,0 +1,343 @@
+package maxigobot
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// mediaServer fakes the upload and message endpoints. It records the
+// upload types requested and the attachments of sent messages.
+type mediaServer struct {
+	t   *testing.T
+	srv *httptest.Server
+
+	mu          sync.Mutex
+	uploadTypes []string
+	uploaded    []string
+	attachments []map[string]any
+}
+
+func newMediaServer(t *testing.T) (*mediaServer, *Bot) {
+	t.Helper()
+	ms := &mediaServer{t: t}
+	ms.srv = httptest.NewServer(http.HandlerFunc(ms.handle))
+	t.Cleanup(ms.srv.Close)
+
+	c, err := maxigo.New("test-token", maxigo.WithBaseURL(ms.srv.URL))
+	if err != nil {
+		t.Fatalf("maxigo.New: %v", err)
+	}
+	b, err := New("test-token", WithClient(c))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return ms, b
+}
+
+func (ms *mediaServer) handle(w http.ResponseWriter, r *http.Request) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/uploads":
+		typ := r.URL.Query().Get("type")
+		ms.uploadTypes = append(ms.uploadTypes, typ)
+		writeJSON(ms.t, w, `{"url":"`+ms.srv.URL+`/upload/`+typ+`"}`)
+	case "/upload/image":
+		ms.recordUpload(r)
+		writeJSON(ms.t, w, `{"photos":{"p":{"token":"photo-token"}}}`)
+	case "/upload/video", "/upload/audio", "/upload/file":
+		ms.recordUpload(r)
+		writeJSON(ms.t, w, `{"token":"media-token"}`)
+	case "/remote.mp4":
+		_, _ = w.Write([]byte("remote video"))
+	case "/messages":
+		var body struct {
+			Attachments []map[string]any `json:"attachments"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		ms.attachments = append(ms.attachments, body.Attachments...)
+		if r.Method == http.MethodPut {
+			writeJSON(ms.t, w, `{"success":true}`)
+			return
+		}
+		writeJSON(ms.t, w, `{"message":{"recipient":{"chat_id":100,"chat_type":"dialog"},"timestamp":1,"body":{"mid":"m","seq":1}}}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (ms *mediaServer) recordUpload(r *http.Request) {
+	f, _, err := r.FormFile("data")
+	if err != nil {
+		ms.t.Errorf("upload without file: %v", err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	data, _ := io.ReadAll(f)
+	ms.uploaded = append(ms.uploaded, string(data))
+}
+
+func (ms *mediaServer) payloads() []map[string]any {
+	var out []map[string]any
+	for _, a := range ms.attachments {
+		p, _ := a["payload"].(map[string]any)
+		out = append(out, p)
+	}
+	return out
+}
+
+func TestContext_SendVideo_sources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(path, []byte("local video"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ms, b := newMediaServer(t)
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+
+	files := []File{
+		FileFromToken("existing"),
+		FileFromPath(path),
+		FileFromReader("clip.mp4", strings.NewReader("reader video")),
+		FileFromURL(ms.srv.URL + "/remote.mp4"),
+	}
+	for _, f := range files {
+		if err := ctx.SendVideo(f); err != nil {
+			t.Fatalf("SendVideo(%+v): %v", f, err)
+		}
+	}
+
+	if got := strings.Join(ms.uploaded, ","); got != "local video,reader video,remote video" {
+		t.Errorf("uploaded = %q", got)
+	}
+	payloads := ms.payloads()
+	if len(payloads) != 4 {
+		t.Fatalf("sent %d attachments, want 4", len(payloads))
+	}
+	if payloads[0]["token"] != "existing" || payloads[1]["token"] != "media-token" {
+		t.Errorf("payloads = %v", payloads)
+	}
+	for _, a := range ms.attachments {
+		if a["type"] != "video" {
+			t.Errorf("attachment type = %v, want video", a["type"])
+		}
+	}
+}
+
+func TestContext_SendAlbum(t *testing.T) {
+	ms, b := newMediaServer(t)
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+
+	err := ctx.SendAlbum([]Media{
+		Photo(FileFromURL("https://example.com/a.jpg")),
+		Photo(FileFromReader("b.jpg", strings.NewReader("b"))),
+		Audio(FileFromReader("c.mp3", strings.NewReader("c"))),
+		Document(FileFromToken("doc")),
+	})
+	if err != nil {
+		t.Fatalf("SendAlbum: %v", err)
+	}
+
+	if got := strings.Join(ms.uploadTypes, ","); got != "image,audio" {
+		t.Errorf("upload types = %q, want image,audio", got)
+	}
+	var types []string
+	for _, a := range ms.attachments {
+		types = append(types, a["type"].(string))
+	}
+	if got := strings.Join(types, ","); got != "image,image,audio,file" {
+		t.Errorf("attachment types = %q", got)
+	}
+	payloads := ms.payloads()
+	if payloads[0]["url"] != "https://example.com/a.jpg" {
+		t.Errorf("photo by URL payload = %v", payloads[0])
+	}
+	if _, ok := payloads[1]["photos"]; !ok {
+		t.Errorf("uploaded photo payload = %v", payloads[1])
+	}
+}
+
+func TestContext_SendStickerLocationContact(t *testing.T) {
+	ms, b := newMediaServer(t)
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+
+	if err := ctx.SendSticker("smile"); err != nil {
+		t.Fatalf("SendSticker: %v", err)
+	}
+	if err := ctx.SendLocation(55.75, 37.62); err != nil {
+		t.Fatalf("SendLocation: %v", err)
+	}
+	if err := ctx.SendContact("Ann", "+70000000000"); err != nil {
+		t.Fatalf("SendContact: %v", err)
+	}
+
+	a := ms.attachments
+	if len(a) != 3 {
+		t.Fatalf("sent %d attachments, want 3", len(a))
+	}
+	if a[0]["type"] != "sticker" || a[0]["payload"].(map[string]any)["code"] != "smile" {
+		t.Errorf("sticker = %v", a[0])
+	}
+	if a[1]["type"] != "location" || a[1]["latitude"] != 55.75 || a[1]["longitude"] != 37.62 {
+		t.Errorf("location = %v", a[1])
+	}
+	if p := a[2]["payload"].(map[string]any); a[2]["type"] != "contact" || p["name"] != "Ann" || p["vcf_phone"] != "+70000000000" {
+		t.Errorf("contact = %v", a[2])
+	}
+}
+
+func TestContext_SendMediaMessage(t *testing.T) {
+	ms, b := newMediaServer(t)
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+	doc := FileFromToken("doc")
+
+	sends := map[string]func() (*maxigo.Message, error){
+		"SendPhotoMessage": func() (*maxigo.Message, error) {
+			return ctx.SendPhotoMessage(&maxigo.PhotoAttachmentRequestPayload{URL: maxigo.Some("https://example.com/a.jpg")})
+		},
+		"SendVideoMessage":    func() (*maxigo.Message, error) { return ctx.SendVideoMessage(doc) },
+		"SendAudioMessage":    func() (*maxigo.Message, error) { return ctx.SendAudioMessage(doc) },
+		"SendFileMessage":     func() (*maxigo.Message, error) { return ctx.SendFileMessage(doc) },
+		"SendAlbumMessage":    func() (*maxigo.Message, error) { return ctx.SendAlbumMessage([]Media{Document(doc)}) },
+		"SendStickerMessage":  func() (*maxigo.Message, error) { return ctx.SendStickerMessage("smile") },
+		"SendLocationMessage": func() (*maxigo.Message, error) { return ctx.SendLocationMessage(55.75, 37.62) },
+		"SendContactMessage":  func() (*maxigo.Message, error) { return ctx.SendContactMessage("Ann", "+70000000000") },
+	}
+	for name, send := range sends {
+		msg, err := send()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if msg == nil || msg.Body.MID != "m" {
+			t.Errorf("%s returned %+v, want MID m", name, msg)
+		}
+	}
+	if len(ms.attachments) != len(sends) {
+		t.Errorf("sent %d attachments, want %d", len(ms.attachments), len(sends))
+	}
+}
+
+func TestContext_SendMedia_errors(t *testing.T) {
+	ms, b := newMediaServer(t)
+
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+	if err := ctx.SendFile(File{}); !errors.Is(err, ErrEmptyFile) {
+		t.Errorf("SendFile(empty) = %v, want ErrEmptyFile", err)
+	}
+	if err := ctx.SendFile(FileFromPath(filepath.Join(t.TempDir(), "missing"))); err == nil {
+		t.Error("SendFile(missing path) should fail")
+	}
+
+	noChat := newTestContext(b, &maxigo.MessageChatCreatedUpdate{})
+	if err := noChat.SendAudio(FileFromReader("a.mp3", strings.NewReader("a"))); !errors.Is(err, ErrNoChatID) {
+		t.Errorf("SendAudio without chat = %v, want ErrNoChatID", err)
+	}
+	if len(ms.uploadTypes) != 0 || len(ms.attachments) != 0 {
+		t.Errorf("unexpected requests: uploads %v, attachments %v", ms.uploadTypes, ms.attachments)
+	}
+}
+
+func TestUpload_fileCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.pdf")
+	if err := os.WriteFile(path, []byte("v1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ms, b := newMediaServer(t)
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+
+	for range 2 {
+		if err := ctx.SendFile(FileFromPath(path)); err != nil {
+			t.Fatalf("SendFile: %v", err)
+		}
+	}
+	if len(ms.uploaded) != 1 {
+		t.Errorf("uploads = %d, want 1 for the same file", len(ms.uploaded))
+	}
+	if p := ms.payloads(); p[1]["token"] != "media-token" {
+		t.Errorf("cached send payload = %v", p[1])
+	}
+
+	// A modified file is uploaded again.
+	if err := os.WriteFile(path, []byte("v2 longer"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.SendFile(FileFromPath(path)); err != nil {
+		t.Fatalf("SendFile: %v", err)
+	}
+	if len(ms.uploaded) != 2 {
+		t.Errorf("uploads = %d, want 2 after the file changed", len(ms.uploaded))
+	}
+
+	// Readers are cached only with a Key.
+	for range 2 {
+		f := FileFromReader("a.mp3", strings.NewReader("a"))
+		f.Key = "intro"
+		if err := ctx.SendAudio(f); err != nil {
+			t.Fatalf("SendAudio: %v", err)
+		}
+		if err := ctx.SendAudio(FileFromReader("b.mp3", strings.NewReader("b"))); err != nil {
+			t.Fatalf("SendAudio: %v", err)
+		}
+	}
+	if len(ms.uploaded) != 5 {
+		t.Errorf("uploads = %d, want 5", len(ms.uploaded))
+	}
+}
+
+func TestWithFileCache_disabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.jpg")
+	if err := os.WriteFile(path, []byte("img"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ms, b := newMediaServer(t)
+	WithFileCache(nil)(b)
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+
+	for range 2 {
+		if err := ctx.SendAlbum([]Media{Photo(FileFromPath(path))}); err != nil {
+			t.Fatalf("SendAlbum: %v", err)
+		}
+	}
+	if len(ms.uploaded) != 2 {
+		t.Errorf("uploads = %d, want 2 without cache", len(ms.uploaded))
+	}
+}
+
+func TestWithMedia(t *testing.T) {
+	ms, b := newMediaServer(t)
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+
+	var kb Keyboard
+	att, _ := kb.Row(kb.Callback("OK", "ok")).Attachment()
+	msg, err := ctx.SendMessage("caption",
+		WithAttachments(att),
+		WithMedia(Video(FileFromReader("v.mp4", strings.NewReader("v")))),
+	)
+	if err != nil || msg == nil {
+		t.Fatalf("SendMessage = %v, %v", msg, err)
+	}
+	if len(ms.attachments) != 2 || ms.attachments[0]["type"] != "video" || ms.attachments[1]["type"] != "inline_keyboard" {
+		t.Errorf("attachments = %v, want video then keyboard", ms.attachments)
+	}
+
+	if err := ctx.EditMessage("m1", "new", WithMedia(Photo(FileFromToken("tok")))); err != nil {
+		t.Fatalf("EditMessage: %v", err)
+	}
+	if len(ms.attachments) != 3 || ms.attachments[2]["type"] != "image" {
+		t.Errorf("edit attachments = %v", ms.attachments)
+	}
+}
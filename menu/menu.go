@@ -0,0 +1,215 @@
+// Package menu builds nested inline menus from a declarative node tree.
+// Opening a node edits the current message in place, and every non-root
+// node gets a "back" button leading to its parent.
+//
+//	settings := menu.New("settings", &menu.Node{
+//		Text: "Settings",
+//		Children: []*menu.Node{
+//			{ID: "lang", Title: "Language", Text: "Choose a language", Children: []*menu.Node{
+//				{ID: "en", Title: "English", Action: setLang("en")},
+//				{ID: "ru", Title: "Русский", Action: setLang("ru")},
+//			}},
+//		},
+//	})
+//
+//	admin := b.Group()
+//	admin.Use(middleware.Whitelist(adminIDs...))
+//	settings.Register(admin)
+//
+//	b.Handle("/settings", settings.Send)
+//
+// All navigation callbacks of a menu share the endpoint OnCallback(unique),
+// so middleware of the group passed to Register applies to the whole menu.
+// Callbacks are not answered by the menu; use middleware.AutoRespond to
+// remove the loading state.
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// RootID is the ID of the root node.
+const RootID = ""
+
+// ErrUnknownNode is returned when a callback refers to a node that is not in the menu.
+var ErrUnknownNode = errors.New("menu: unknown node")
+
+// Node is a menu screen, or an action when Action is set.
+type Node struct {
+	// ID identifies the node in callback payloads. It must be unique within
+	// the menu and must not contain the callback separator. The root node's
+	// ID is ignored.
+	ID string
+	// Title is the label of the button that opens the node from its parent.
+	Title string
+	// Text is the message text shown when the node is open.
+	Text string
+	// Children are shown as buttons, Columns per row (default 1).
+	Children []*Node
+	Columns  int
+	// Buttons are extra buttons shown in a row below the children,
+	// e.g. links.
+	Buttons []maxigo.Button
+	// Action, if set, is called when the node's button is pressed instead
+	// of opening the node. Use Menu.Open to navigate from an action.
+	Action maxigobot.HandlerFunc
+}
+
+// Option configures a Menu.
+type Option func(*Menu)
+
+// WithBackText sets the label of the back button. Default: "« Back".
+func WithBackText(text string) Option {
+	return func(m *Menu) {
+		m.backText = text
+	}
+}
+
+// WithSeparator sets the callback separator used in navigation payloads.
+// It must match maxigobot.WithCallbackSeparator.
+// Default: maxigobot.DefaultCallbackSeparator.
+func WithSeparator(sep string) Option {
+	return func(m *Menu) {
+		m.sep = sep
+	}
+}
+
+// Menu is a tree of nodes navigated with callbacks. It is immutable after
+// New and safe for concurrent use.
+type Menu struct {
+	unique   string
+	root     *Node
+	nodes    map[string]*Node
+	parents  map[string]string
+	backText string
+	sep      string
+}
+
+// New creates a menu rooted at root. unique identifies the menu's
+// callbacks and must not be used by other callback handlers.
+// Panics if a node ID is empty, duplicated or contains the separator,
+// since menus are built at setup time.
+func New(unique string, root *Node, opts ...Option) *Menu {
+	m := &Menu{
+		unique:   unique,
+		root:     root,
+		nodes:    map[string]*Node{RootID: root},
+		parents:  make(map[string]string),
+		backText: "« Back",
+		sep:      maxigobot.DefaultCallbackSeparator,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.index(RootID, root)
+	return m
+}
+
+// index records the children of n and their parents.
+func (m *Menu) index(id string, n *Node) {
+	for _, child := range n.Children {
+		if child.ID == RootID || strings.Contains(child.ID, m.sep) {
+			panic(fmt.Sprintf("menu: invalid node ID %q", child.ID))
+		}
+		if _, ok := m.nodes[child.ID]; ok {
+			panic(fmt.Sprintf("menu: duplicate node ID %q", child.ID))
+		}
+		m.nodes[child.ID] = child
+		m.parents[child.ID] = id
+		m.index(child.ID, child)
+	}
+}
+
+// Endpoint returns the endpoint of the menu callbacks.
+func (m *Menu) Endpoint() string { return maxigobot.OnCallback(m.unique) }
+
+// Register registers the menu's callback handler in g. Group middleware
+// applies to every node of the menu.
+func (m *Menu) Register(g *maxigobot.Group) {
+	g.Handle(m.Endpoint(), m.handle)
+}
+
+// Send sends the root node as a new message. It can be used as a handler.
+func (m *Menu) Send(c maxigobot.Context) error {
+	text, opts, err := m.message(RootID)
+	if err != nil {
+		return err
+	}
+	return c.Send(text, opts...)
+}
+
+// Open shows the node with the given ID by editing the current message.
+func (m *Menu) Open(c maxigobot.Context, id string) error {
+	text, opts, err := m.message(id)
+	if err != nil {
+		return err
+	}
+	return c.Edit(text, opts...)
+}
+
+// handle opens the node from the callback payload or runs its action.
+func (m *Menu) handle(c maxigobot.Context) error {
+	id := RootID
+	if args := c.CallbackArgs(); len(args) > 0 {
+		id = strings.Join(args, m.sep)
+	}
+	node, ok := m.nodes[id]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownNode, id)
+	}
+	if node.Action != nil {
+		return node.Action(c)
+	}
+	return m.Open(c, id)
+}
+
+// render returns the text and keyboard of a node.
+func (m *Menu) render(id string) (string, maxigobot.Keyboard, error) {
+	var kb maxigobot.Keyboard
+	node, ok := m.nodes[id]
+	if !ok {
+		return "", kb, fmt.Errorf("%w: %q", ErrUnknownNode, id)
+	}
+
+	buttons := make([]maxigo.Button, len(node.Children))
+	for i, child := range node.Children {
+		buttons[i] = kb.Callback(child.Title, m.payload(child.ID))
+	}
+	kb = kb.Grid(buttons, max(node.Columns, 1))
+	if len(node.Buttons) > 0 {
+		kb = kb.Row(node.Buttons...)
+	}
+	if parent, ok := m.parents[id]; ok {
+		kb = kb.Row(kb.Callback(m.backText, m.payload(parent)))
+	}
+	return node.Text, kb, nil
+}
+
+// message returns the text and send options of a node.
+// A node without buttons is sent without a keyboard.
+func (m *Menu) message(id string) (string, []maxigobot.SendOption, error) {
+	text, kb, err := m.render(id)
+	if err != nil || len(kb.Rows()) == 0 {
+		return text, nil, err
+	}
+	att, err := kb.Attachment()
+	if err != nil {
+		return "", nil, err
+	}
+	return text, []maxigobot.SendOption{maxigobot.WithAttachments(att)}, nil
+}
+
+// payload returns the callback payload that opens the node. The root node
+// is addressed by the bare unique.
+func (m *Menu) payload(id string) string {
+	if id == RootID {
+		return m.unique
+	}
+	return m.unique + m.sep + id
+}
@@ -0,0 +1,168 @@
+package menu
+
+import (
+	"errors"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// menuContext is a minimal Context stub that records sent and edited messages.
+type menuContext struct {
+	maxigobot.Context
+	args   []string
+	sent   string
+	edited string
+	opts   []maxigobot.SendOption
+}
+
+func (c *menuContext) CallbackArgs() []string { return c.args }
+
+func (c *menuContext) Send(text string, opts ...maxigobot.SendOption) error {
+	c.sent, c.opts = text, opts
+	return nil
+}
+
+func (c *menuContext) Edit(text string, opts ...maxigobot.SendOption) error {
+	c.edited, c.opts = text, opts
+	return nil
+}
+
+func testMenu(action maxigobot.HandlerFunc) *Menu {
+	return New("settings", &Node{
+		Text: "Settings",
+		Children: []*Node{
+			{ID: "lang", Title: "Language", Text: "Choose a language", Columns: 2, Children: []*Node{
+				{ID: "en", Title: "English", Action: action},
+				{ID: "ru", Title: "Русский", Action: action},
+			}},
+			{ID: "about", Title: "About", Text: "v1.0", Buttons: []maxigo.Button{
+				maxigo.NewLinkButton("Site", "https://example.com"),
+			}},
+		},
+	}, WithBackText("Back"))
+}
+
+func rows(t *testing.T, m *Menu, id string) (string, [][]maxigo.Button) {
+	t.Helper()
+	text, kb, err := m.render(id)
+	if err != nil {
+		t.Fatalf("render(%q): %v", id, err)
+	}
+	return text, kb.Rows()
+}
+
+func TestMenu_render(t *testing.T) {
+	m := testMenu(nil)
+
+	text, kb := rows(t, m, RootID)
+	if text != "Settings" || len(kb) != 2 {
+		t.Fatalf("root = %q with %d rows, want Settings with 2 rows", text, len(kb))
+	}
+	if kb[0][0].Payload != "settings:lang" || kb[1][0].Payload != "settings:about" {
+		t.Errorf("root buttons = %+v", kb)
+	}
+
+	text, kb = rows(t, m, "lang")
+	if text != "Choose a language" || len(kb) != 2 || len(kb[0]) != 2 {
+		t.Fatalf("lang = %q with rows %+v", text, kb)
+	}
+	if back := kb[1][0]; back.Text != "Back" || back.Payload != "settings" {
+		t.Errorf("back button = %+v, want payload %q", back, "settings")
+	}
+
+	_, kb = rows(t, m, "about")
+	if len(kb) != 2 || kb[0][0].Type != "link" || kb[1][0].Payload != "settings" {
+		t.Errorf("about rows = %+v", kb)
+	}
+
+	_, kb = rows(t, m, "en")
+	if len(kb) != 1 || kb[0][0].Payload != "settings:lang" {
+		t.Errorf("leaf rows = %+v, want only back to lang", kb)
+	}
+}
+
+func TestMenu_handle(t *testing.T) {
+	var acted []string
+	m := testMenu(func(c maxigobot.Context) error {
+		acted = append(acted, c.CallbackArgs()[0])
+		return nil
+	})
+
+	c := &menuContext{args: []string{"lang"}}
+	if err := m.handle(c); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if c.edited != "Choose a language" {
+		t.Errorf("edited = %q", c.edited)
+	}
+
+	c = &menuContext{}
+	if err := m.handle(c); err != nil {
+		t.Fatalf("handle root: %v", err)
+	}
+	if c.edited != "Settings" {
+		t.Errorf("edited = %q, want root", c.edited)
+	}
+
+	c = &menuContext{args: []string{"ru"}}
+	if err := m.handle(c); err != nil {
+		t.Fatalf("handle action: %v", err)
+	}
+	if c.edited != "" || len(acted) != 1 || acted[0] != "ru" {
+		t.Errorf("action not called instead of navigation: edited %q, acted %q", c.edited, acted)
+	}
+
+	if err := m.handle(&menuContext{args: []string{"nope"}}); !errors.Is(err, ErrUnknownNode) {
+		t.Errorf("handle unknown = %v, want ErrUnknownNode", err)
+	}
+}
+
+func TestMenu_Send(t *testing.T) {
+	m := testMenu(nil)
+	c := &menuContext{}
+	if err := m.Send(c); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if c.sent != "Settings" || len(c.opts) != 1 {
+		t.Errorf("sent %q with %d options", c.sent, len(c.opts))
+	}
+}
+
+func TestMenu_Register(t *testing.T) {
+	b, err := maxigobot.New("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testMenu(nil).Register(b.Group())
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on conflicting callback endpoint")
+		}
+	}()
+	b.Handle(maxigobot.OnCallback("settings:lang"), func(maxigobot.Context) error { return nil })
+}
+
+func TestNew_invalidNodes(t *testing.T) {
+	tests := []struct {
+		name string
+		root *Node
+	}{
+		{"empty ID", &Node{Children: []*Node{{Title: "x"}}}},
+		{"separator in ID", &Node{Children: []*Node{{ID: "a:b"}}}},
+		{"duplicate ID", &Node{Children: []*Node{{ID: "a"}, {ID: "b", Children: []*Node{{ID: "a"}}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			New("m", tt.root)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"time"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// DeleteTriggerConfig defines the config for DeleteTrigger middleware.
+type DeleteTriggerConfig struct {
+	// Skipper defines a function to skip this middleware.
+	Skipper Skipper
+	// Delay postpones the deletion (see maxigobot.Bot.DeleteAfter).
+	// Default: 0 — delete right after the handler.
+	Delay time.Duration
+}
+
+// DefaultDeleteTriggerConfig is the default DeleteTrigger middleware config.
+var DefaultDeleteTriggerConfig = DeleteTriggerConfig{
+	Skipper: DefaultSkipper,
+}
+
+// DeleteTrigger returns a middleware that deletes the user's message that
+// triggered the handler after the handler completes, e.g. to keep a chat
+// clean of commands. Callback updates are left alone: their message
+// belongs to the bot.
+func DeleteTrigger() maxigobot.MiddlewareFunc {
+	return DeleteTriggerWithConfig(DefaultDeleteTriggerConfig)
+}
+
+// DeleteTriggerWithConfig returns a DeleteTrigger middleware with custom config.
+func DeleteTriggerWithConfig(cfg DeleteTriggerConfig) maxigobot.MiddlewareFunc {
+	if cfg.Skipper == nil {
+		cfg.Skipper = DefaultDeleteTriggerConfig.Skipper
+	}
+
+	return func(next maxigobot.HandlerFunc) maxigobot.HandlerFunc {
+		return func(c maxigobot.Context) error {
+			if cfg.Skipper(c) {
+				return next(c)
+			}
+
+			err := next(c)
+
+			msg := c.Message()
+			if msg == nil || c.Callback() != nil {
+				return err
+			}
+			var delErr error
+			if cfg.Delay > 0 && c.Bot() != nil {
+				delErr = c.Bot().DeleteAfter(c.Chat(), msg.Body.MID, cfg.Delay)
+			} else {
+				delErr = c.Delete()
+			}
+			if err != nil {
+				return err
+			}
+			return delErr
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"errors"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+func TestDeleteTrigger_message(t *testing.T) {
+	mw := DeleteTrigger()
+	ctx := &mockContext{message: &maxigo.Message{Body: maxigo.MessageBody{MID: "m1"}}}
+
+	handlerCalled := false
+	handler := mw(func(c maxigobot.Context) error {
+		handlerCalled = true
+		if ctx.deleteCalled {
+			t.Error("message should be deleted after the handler")
+		}
+		return nil
+	})
+
+	if err := handler(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !handlerCalled {
+		t.Error("handler was not called")
+	}
+	if !ctx.deleteCalled {
+		t.Error("Delete should be called for message updates")
+	}
+}
+
+func TestDeleteTrigger_callback(t *testing.T) {
+	mw := DeleteTrigger()
+	ctx := &mockContext{
+		message:  &maxigo.Message{Body: maxigo.MessageBody{MID: "bot-msg"}},
+		callback: &maxigo.Callback{CallbackID: "cb1"},
+	}
+
+	_ = mw(func(c maxigobot.Context) error { return nil })(ctx)
+	if ctx.deleteCalled {
+		t.Error("Delete should not be called for callback updates")
+	}
+}
+
+func TestDeleteTrigger_errors(t *testing.T) {
+	mw := DeleteTrigger()
+	handlerErr := errors.New("handler")
+	deleteErr := errors.New("delete")
+
+	ctx := &mockContext{message: &maxigo.Message{}, deleteErr: deleteErr}
+	if err := mw(func(c maxigobot.Context) error { return handlerErr })(ctx); !errors.Is(err, handlerErr) {
+		t.Errorf("err = %v, want handler error", err)
+	}
+	if !ctx.deleteCalled {
+		t.Error("Delete should be called even if the handler fails")
+	}
+
+	ctx = &mockContext{message: &maxigo.Message{}, deleteErr: deleteErr}
+	if err := mw(func(c maxigobot.Context) error { return nil })(ctx); !errors.Is(err, deleteErr) {
+		t.Errorf("err = %v, want delete error", err)
+	}
+}
+
+func TestDeleteTrigger_skipper(t *testing.T) {
+	mw := DeleteTriggerWithConfig(DeleteTriggerConfig{
+		Skipper: func(c maxigobot.Context) bool { return true },
+	})
+	ctx := &mockContext{message: &maxigo.Message{}}
+
+	_ = mw(func(c maxigobot.Context) error { return nil })(ctx)
+	if ctx.deleteCalled {
+		t.Error("Delete should not be called when skipped")
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	gocontext "context"
+	"io"
 
 	maxigo "github.com/maxigo-bot/maxigo-client"
 	maxigobot "github.com/maxigo-bot/maxigo-bot"
@@ -16,16 +17,25 @@ type mockContext struct {
 	callback *maxigo.Callback
 	text     string
 	store    map[string]any
+	ctx      gocontext.Context
 
 	// Tracking calls for assertions.
 	respondCalled bool
 	respondText   string
+	deleteCalled  bool
+	deleteErr     error
 }
 
 func (m *mockContext) Bot() *maxigobot.Bot       { return nil }
 func (m *mockContext) Update() maxigo.Update      { return m.update }
 func (m *mockContext) API() *maxigo.Client        { return nil }
-func (m *mockContext) Ctx() gocontext.Context     { return gocontext.Background() }
+func (m *mockContext) Ctx() gocontext.Context {
+	if m.ctx != nil {
+		return m.ctx
+	}
+	return gocontext.Background()
+}
+func (m *mockContext) SetCtx(ctx gocontext.Context) { m.ctx = ctx }
 func (m *mockContext) Sender() *maxigo.User       { return m.sender }
 func (m *mockContext) Chat() int64                { return m.chatID }
 func (m *mockContext) Message() *maxigo.Message   { return m.message }
@@ -33,6 +43,7 @@ func (m *mockContext) Text() string               { return m.text }
 func (m *mockContext) Command() string            { return "" }
 func (m *mockContext) Payload() string            { return "" }
 func (m *mockContext) Args() []string             { return nil }
+func (m *mockContext) Match() []string            { return nil }
 func (m *mockContext) Callback() *maxigo.Callback { return m.callback }
 func (m *mockContext) Data() string {
 	if m.callback != nil {
@@ -41,13 +52,67 @@ func (m *mockContext) Data() string {
 	return ""
 }
 
+func (m *mockContext) CallbackArgs() []string { return nil }
+func (m *mockContext) Attachments() []maxigo.Attachment { return nil }
+func (m *mockContext) Photos() []*maxigo.PhotoAttachment { return nil }
+func (m *mockContext) Files() []*maxigo.FileAttachment { return nil }
+func (m *mockContext) Location() *maxigo.LocationAttachment { return nil }
+func (m *mockContext) Contact() (*maxigo.ContactAttachment, bool) { return nil, false }
+func (m *mockContext) Download(_ maxigo.Attachment, _ io.Writer) error { return nil }
+
 func (m *mockContext) Send(_ string, _ ...maxigobot.SendOption) error      { return nil }
 func (m *mockContext) Reply(_ string, _ ...maxigobot.SendOption) error     { return nil }
 func (m *mockContext) Edit(_ string, _ ...maxigobot.SendOption) error      { return nil }
-func (m *mockContext) Delete() error                                       { return nil }
+func (m *mockContext) SendMessage(_ string, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
+func (m *mockContext) ReplyMessage(_ string, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
+func (m *mockContext) EditMessage(_, _ string, _ ...maxigobot.SendOption) error { return nil }
+func (m *mockContext) DeleteMessage(_ string) error                           { return nil }
+func (m *mockContext) Delete() error {
+	m.deleteCalled = true
+	return m.deleteErr
+}
 func (m *mockContext) SendPhoto(_ *maxigo.PhotoAttachmentRequestPayload, _ ...maxigobot.SendOption) error {
 	return nil
 }
+func (m *mockContext) SendVideo(_ maxigobot.File, _ ...maxigobot.SendOption) error { return nil }
+func (m *mockContext) SendAudio(_ maxigobot.File, _ ...maxigobot.SendOption) error { return nil }
+func (m *mockContext) SendFile(_ maxigobot.File, _ ...maxigobot.SendOption) error  { return nil }
+func (m *mockContext) SendAlbum(_ []maxigobot.Media, _ ...maxigobot.SendOption) error {
+	return nil
+}
+func (m *mockContext) SendSticker(_ string, _ ...maxigobot.SendOption) error { return nil }
+func (m *mockContext) SendLocation(_, _ float64, _ ...maxigobot.SendOption) error {
+	return nil
+}
+func (m *mockContext) SendContact(_, _ string, _ ...maxigobot.SendOption) error { return nil }
+func (m *mockContext) SendPhotoMessage(_ *maxigo.PhotoAttachmentRequestPayload, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
+func (m *mockContext) SendVideoMessage(_ maxigobot.File, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
+func (m *mockContext) SendAudioMessage(_ maxigobot.File, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
+func (m *mockContext) SendFileMessage(_ maxigobot.File, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
+func (m *mockContext) SendAlbumMessage(_ []maxigobot.Media, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
+func (m *mockContext) SendStickerMessage(_ string, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
+func (m *mockContext) SendLocationMessage(_, _ float64, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
+func (m *mockContext) SendContactMessage(_, _ string, _ ...maxigobot.SendOption) (*maxigo.Message, error) {
+	return nil, nil
+}
 
 func (m *mockContext) Respond(text string) error {
 	m.respondCalled = true
@@ -70,3 +135,6 @@ func (m *mockContext) Set(key string, val any) {
 	}
 	m.store[key] = val
 }
+
+func (m *mockContext) State() string              { return "" }
+func (m *mockContext) SetState(_ string) error    { return nil }
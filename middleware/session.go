@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// SessionKeyFunc returns the storage key of the session for an update.
+// Returning "" disables the session for that update.
+type SessionKeyFunc func(c maxigobot.Context) string
+
+// SessionByChat keys sessions by chat: all members of a chat share one session.
+func SessionByChat(c maxigobot.Context) string {
+	if c.Chat() == 0 {
+		return ""
+	}
+	return "chat:" + strconv.FormatInt(c.Chat(), 10)
+}
+
+// SessionByUser keys sessions by sender: a user has one session across all chats.
+func SessionByUser(c maxigobot.Context) string {
+	if c.Sender() == nil {
+		return ""
+	}
+	return "user:" + strconv.FormatInt(c.Sender().UserID, 10)
+}
+
+// SessionByChatUser keys sessions by chat and sender: a user has a separate
+// session in every chat.
+func SessionByChatUser(c maxigobot.Context) string {
+	if c.Chat() == 0 || c.Sender() == nil {
+		return ""
+	}
+	return "chat:" + strconv.FormatInt(c.Chat(), 10) + ":user:" + strconv.FormatInt(c.Sender().UserID, 10)
+}
+
+// DefaultSessionContextKey is the context store key under which the session is exposed.
+const DefaultSessionContextKey = "session"
+
+// SessionConfig defines the config for Session middleware.
+type SessionConfig struct {
+	// Skipper defines a function to skip this middleware.
+	Skipper Skipper
+
+	// Store persists sessions. Default: a new in-memory store without expiry.
+	Store SessionStore
+
+	// Key selects how sessions are keyed. Default: SessionByChatUser.
+	Key SessionKeyFunc
+
+	// ContextKey is the key under which the session is stored in the
+	// context (c.Get); read it with GetSessionKey.
+	// Default: DefaultSessionContextKey.
+	ContextKey string
+}
+
+// DefaultSessionConfig is the default Session middleware config.
+var DefaultSessionConfig = SessionConfig{
+	Skipper:    DefaultSkipper,
+	Key:        SessionByChatUser,
+	ContextKey: DefaultSessionContextKey,
+}
+
+// Session returns a middleware that loads a session of type T from store
+// before the handler and saves it afterwards if it was modified. Handlers
+// access the session with GetSession:
+//
+//	type Cart struct{ Items []string }
+//
+//	b.Use(middleware.Session[Cart](middleware.NewMemorySessionStore(24 * time.Hour)))
+//	b.Handle("/add", func(c maxigobot.Context) error {
+//		cart := middleware.GetSession[Cart](c)
+//		cart.Items = append(cart.Items, c.Payload())
+//		return nil
+//	})
+//
+// Sessions are encoded as JSON, so T must be JSON-serializable.
+func Session[T any](store SessionStore) maxigobot.MiddlewareFunc {
+	cfg := DefaultSessionConfig
+	cfg.Store = store
+	return SessionWithConfig[T](cfg)
+}
+
+// SessionWithConfig returns a Session middleware with custom config.
+func SessionWithConfig[T any](cfg SessionConfig) maxigobot.MiddlewareFunc {
+	if cfg.Skipper == nil {
+		cfg.Skipper = DefaultSessionConfig.Skipper
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemorySessionStore(0)
+	}
+	if cfg.Key == nil {
+		cfg.Key = DefaultSessionConfig.Key
+	}
+	if cfg.ContextKey == "" {
+		cfg.ContextKey = DefaultSessionConfig.ContextKey
+	}
+
+	return func(next maxigobot.HandlerFunc) maxigobot.HandlerFunc {
+		return func(c maxigobot.Context) error {
+			if cfg.Skipper(c) {
+				return next(c)
+			}
+			key := cfg.Key(c)
+			if key == "" {
+				return next(c)
+			}
+
+			original, err := cfg.Store.Get(c.Ctx(), key)
+			if err != nil {
+				return fmt.Errorf("session: load %q: %w", key, err)
+			}
+			sess := new(T)
+			if original != nil {
+				if err := json.Unmarshal(original, sess); err != nil {
+					return fmt.Errorf("session: decode %q: %w", key, err)
+				}
+			} else if original, err = json.Marshal(sess); err != nil {
+				return fmt.Errorf("session: encode %q: %w", key, err)
+			}
+			c.Set(cfg.ContextKey, sess)
+
+			handlerErr := next(c)
+
+			data, err := json.Marshal(sess)
+			if err != nil {
+				return errors.Join(handlerErr, fmt.Errorf("session: encode %q: %w", key, err))
+			}
+			if bytes.Equal(data, original) {
+				return handlerErr
+			}
+			if err := cfg.Store.Set(c.Ctx(), key, data); err != nil {
+				return errors.Join(handlerErr, fmt.Errorf("session: save %q: %w", key, err))
+			}
+			return handlerErr
+		}
+	}
+}
+
+// GetSession returns the session loaded by the Session middleware under
+// DefaultSessionContextKey, or nil if the middleware did not run for this
+// update or T does not match the session type.
+func GetSession[T any](c maxigobot.Context) *T {
+	return GetSessionKey[T](c, DefaultSessionContextKey)
+}
+
+// GetSessionKey is like GetSession for a session stored under a custom
+// SessionConfig.ContextKey.
+func GetSessionKey[T any](c maxigobot.Context, key string) *T {
+	sess, _ := c.Get(key).(*T)
+	return sess
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/maxigo-bot/maxigo-bot/internal/fileutil"
+)
+
+// SessionStore persists encoded sessions for the Session middleware.
+// Implementations must be safe for concurrent use. Backends such as Redis
+// or an embedded key-value database can be plugged in by implementing it.
+type SessionStore interface {
+	// Get returns the session data for key, or nil if there is none.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores the session data for key.
+	Set(ctx context.Context, key string, data []byte) error
+	// Delete removes the session for key.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemorySessionStore is an in-memory SessionStore with optional expiry.
+// Sessions are lost on restart.
+type MemorySessionStore struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	items     map[string]memorySession
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memorySession struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// NewMemorySessionStore creates an in-memory store. Sessions not accessed
+// for ttl are evicted; a ttl of 0 keeps sessions forever.
+func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
+	return &MemorySessionStore{
+		ttl:   ttl,
+		items: make(map[string]memorySession),
+		now:   time.Now,
+	}
+}
+
+// Get implements SessionStore. Reading a session extends its lifetime.
+func (s *MemorySessionStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[key]
+	if !ok {
+		return nil, nil
+	}
+	now := s.now()
+	if s.expired(item, now) {
+		delete(s.items, key)
+		return nil, nil
+	}
+	if s.ttl > 0 {
+		item.expiresAt = now.Add(s.ttl)
+		s.items[key] = item
+	}
+	return item.data, nil
+}
+
+// Set implements SessionStore.
+func (s *MemorySessionStore) Set(_ context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	item := memorySession{data: data}
+	if s.ttl > 0 {
+		item.expiresAt = now.Add(s.ttl)
+	}
+	s.items[key] = item
+	s.sweep(now)
+	return nil
+}
+
+// Delete implements SessionStore.
+func (s *MemorySessionStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+	return nil
+}
+
+// Len returns the number of stored sessions, including expired ones that
+// have not been evicted yet.
+func (s *MemorySessionStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+func (s *MemorySessionStore) expired(item memorySession, now time.Time) bool {
+	return !item.expiresAt.IsZero() && now.After(item.expiresAt)
+}
+
+// sweep evicts expired sessions at most once per ttl. Caller must hold s.mu.
+func (s *MemorySessionStore) sweep(now time.Time) {
+	if s.ttl <= 0 || now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	s.lastSweep = now
+	for k, item := range s.items {
+		if s.expired(item, now) {
+			delete(s.items, k)
+		}
+	}
+}
+
+// FileSessionStore is a SessionStore that keeps every session in its own
+// file inside a directory, so sessions survive restarts.
+type FileSessionStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileSessionStore creates a file-backed store in dir, creating the
+// directory if needed.
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("session: create store dir: %w", err)
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+// Get implements SessionStore.
+func (s *FileSessionStore) Get(_ context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Set implements SessionStore. The file is replaced atomically.
+func (s *FileSessionStore) Set(_ context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fileutil.WriteAtomic(s.path(key), data)
+}
+
+// Delete implements SessionStore.
+func (s *FileSessionStore) Delete(_ context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// path maps a session key to a file name that is safe on every platform.
+func (s *FileSessionStore) path(key string) string {
+	return filepath.Join(s.dir, base64.RawURLEncoding.EncodeToString([]byte(key))+".json")
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func testSessionStore(t *testing.T, s SessionStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if data, err := s.Get(ctx, "chat:1"); err != nil || data != nil {
+		t.Fatalf("Get missing = (%q, %v), want (nil, nil)", data, err)
+	}
+	if err := s.Set(ctx, "chat:1", []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if data, _ := s.Get(ctx, "chat:1"); string(data) != `{"a":1}` {
+		t.Errorf("Get = %q", data)
+	}
+	if err := s.Delete(ctx, "chat:1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if data, _ := s.Get(ctx, "chat:1"); data != nil {
+		t.Errorf("Get after Delete = %q, want nil", data)
+	}
+	if err := s.Delete(ctx, "chat:1"); err != nil {
+		t.Errorf("Delete missing: %v", err)
+	}
+}
+
+func TestMemorySessionStore(t *testing.T) {
+	testSessionStore(t, NewMemorySessionStore(time.Hour))
+}
+
+func TestMemorySessionStore_ttl(t *testing.T) {
+	s := NewMemorySessionStore(time.Minute)
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_ = s.Set(ctx, "a", []byte("1"))
+	_ = s.Set(ctx, "b", []byte("2"))
+
+	now = now.Add(50 * time.Second)
+	if data, _ := s.Get(ctx, "a"); data == nil {
+		t.Fatal("session a expired too early")
+	}
+
+	// a was refreshed by Get, b was not.
+	now = now.Add(30 * time.Second)
+	if data, _ := s.Get(ctx, "b"); data != nil {
+		t.Error("session b should have expired")
+	}
+	if data, _ := s.Get(ctx, "a"); data == nil {
+		t.Error("session a should still be alive after access")
+	}
+
+	// Set sweeps expired sessions.
+	now = now.Add(2 * time.Minute)
+	_ = s.Set(ctx, "c", []byte("3"))
+	if s.Len() != 1 {
+		t.Errorf("Len after sweep = %d, want 1", s.Len())
+	}
+}
+
+func TestFileSessionStore(t *testing.T) {
+	s, err := NewFileSessionStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	testSessionStore(t, s)
+}
+
+func TestFileSessionStore_persists(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := NewFileSessionStore(dir)
+	_ = s.Set(context.Background(), "chat:-1:user:2", []byte(`{"step":2}`))
+
+	reopened, err := NewFileSessionStore(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if data, _ := reopened.Get(context.Background(), "chat:-1:user:2"); string(data) != `{"step":2}` {
+		t.Errorf("Get after reopen = %q", data)
+	}
+}
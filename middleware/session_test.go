@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+type testSession struct {
+	Count int    `json:"count"`
+	Name  string `json:"name,omitempty"`
+}
+
+// countingStore wraps a SessionStore and counts writes.
+type countingStore struct {
+	SessionStore
+	sets int
+}
+
+func (s *countingStore) Set(ctx context.Context, key string, data []byte) error {
+	s.sets++
+	return s.SessionStore.Set(ctx, key, data)
+}
+
+func TestSession_persistsBetweenUpdates(t *testing.T) {
+	store := NewMemorySessionStore(0)
+	mw := Session[testSession](store)
+	handler := mw(func(c maxigobot.Context) error {
+		GetSession[testSession](c).Count++
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		ctx := &mockContext{chatID: 1, sender: &maxigo.User{UserID: 2}}
+		if err := handler(ctx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	data, _ := store.Get(context.Background(), "chat:1:user:2")
+	if string(data) != `{"count":3}` {
+		t.Errorf("stored session = %s, want {\"count\":3}", data)
+	}
+}
+
+func TestSessionWithConfig_contextKey(t *testing.T) {
+	store := NewMemorySessionStore(0)
+	handler := SessionWithConfig[testSession](SessionConfig{
+		Store:      store,
+		ContextKey: "cart",
+	})(func(c maxigobot.Context) error {
+		if GetSession[testSession](c) != nil {
+			t.Error("GetSession found a session under the default key")
+		}
+		GetSessionKey[testSession](c, "cart").Count++
+		return nil
+	})
+
+	if err := handler(&mockContext{chatID: 1, sender: &maxigo.User{UserID: 2}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, _ := store.Get(context.Background(), "chat:1:user:2")
+	if string(data) != `{"count":1}` {
+		t.Errorf("stored session = %s, want {\"count\":1}", data)
+	}
+}
+
+func TestSession_savesOnlyWhenModified(t *testing.T) {
+	store := &countingStore{SessionStore: NewMemorySessionStore(0)}
+	mw := Session[testSession](store)
+
+	read := mw(func(c maxigobot.Context) error {
+		_ = GetSession[testSession](c).Count
+		return nil
+	})
+	write := mw(func(c maxigobot.Context) error {
+		GetSession[testSession](c).Name = "Ann"
+		return nil
+	})
+
+	ctx := func() *mockContext { return &mockContext{chatID: 1, sender: &maxigo.User{UserID: 2}} }
+
+	_ = read(ctx())
+	if store.sets != 0 {
+		t.Fatalf("unmodified new session was saved (%d writes)", store.sets)
+	}
+	_ = write(ctx())
+	_ = read(ctx())
+	_ = write(ctx())
+	if store.sets != 1 {
+		t.Errorf("writes = %d, want 1", store.sets)
+	}
+}
+
+func TestSession_keyFuncs(t *testing.T) {
+	ctx := &mockContext{chatID: -5, sender: &maxigo.User{UserID: 7}}
+
+	if got := SessionByChat(ctx); got != "chat:-5" {
+		t.Errorf("SessionByChat = %q", got)
+	}
+	if got := SessionByUser(ctx); got != "user:7" {
+		t.Errorf("SessionByUser = %q", got)
+	}
+	if got := SessionByChatUser(ctx); got != "chat:-5:user:7" {
+		t.Errorf("SessionByChatUser = %q", got)
+	}
+	if got := SessionByUser(&mockContext{}); got != "" {
+		t.Errorf("SessionByUser without sender = %q, want empty", got)
+	}
+}
+
+func TestSession_noKeySkipsSession(t *testing.T) {
+	mw := SessionWithConfig[testSession](SessionConfig{Key: SessionByUser})
+
+	var sess *testSession
+	handler := mw(func(c maxigobot.Context) error {
+		sess = GetSession[testSession](c)
+		return nil
+	})
+
+	if err := handler(&mockContext{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sess != nil {
+		t.Error("session should be nil when the update has no key")
+	}
+}
+
+func TestSession_savesAndPropagatesHandlerError(t *testing.T) {
+	store := NewMemorySessionStore(0)
+	mw := Session[testSession](store)
+	handler := mw(func(c maxigobot.Context) error {
+		GetSession[testSession](c).Count = 5
+		return errForTest("handler failed")
+	})
+
+	err := handler(&mockContext{chatID: 1, sender: &maxigo.User{UserID: 1}})
+	if err == nil || err.Error() != "handler failed" {
+		t.Errorf("error = %v, want 'handler failed'", err)
+	}
+	if data, _ := store.Get(context.Background(), "chat:1:user:1"); string(data) != `{"count":5}` {
+		t.Errorf("stored session = %s", data)
+	}
+}
+
+func TestSession_loadError(t *testing.T) {
+	mw := Session[testSession](failingStore{})
+	called := false
+	handler := mw(func(c maxigobot.Context) error {
+		called = true
+		return nil
+	})
+
+	err := handler(&mockContext{chatID: 1, sender: &maxigo.User{UserID: 1}})
+	if !errors.Is(err, errStoreDown) {
+		t.Errorf("error = %v, want errStoreDown", err)
+	}
+	if called {
+		t.Error("handler should not run when the session cannot be loaded")
+	}
+}
+
+func TestSession_skipper(t *testing.T) {
+	mw := SessionWithConfig[testSession](SessionConfig{
+		Skipper: func(_ maxigobot.Context) bool { return true },
+	})
+	var sess *testSession
+	handler := mw(func(c maxigobot.Context) error {
+		sess = GetSession[testSession](c)
+		return nil
+	})
+
+	if err := handler(&mockContext{chatID: 1, sender: &maxigo.User{UserID: 1}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sess != nil {
+		t.Error("session should not be loaded when skipped")
+	}
+}
+
+var errStoreDown = errors.New("store down")
+
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) ([]byte, error) { return nil, errStoreDown }
+func (failingStore) Set(context.Context, string, []byte) error   { return errStoreDown }
+func (failingStore) Delete(context.Context, string) error        { return errStoreDown }
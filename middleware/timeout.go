@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	gocontext "context"
+	"time"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// TimeoutConfig defines the config for Timeout middleware.
+type TimeoutConfig struct {
+	// Skipper defines a function to skip this middleware.
+	Skipper Skipper
+	// Timeout is the time allowed to the next handlers.
+	// Default: 30 seconds.
+	Timeout time.Duration
+}
+
+// DefaultTimeoutConfig is the default Timeout middleware config.
+var DefaultTimeoutConfig = TimeoutConfig{
+	Skipper: DefaultSkipper,
+	Timeout: 30 * time.Second,
+}
+
+// Timeout returns a Timeout middleware that gives the next handlers
+// timeout to complete. It replaces the request-scoped context (c.Ctx())
+// with one that is canceled after timeout, which aborts API calls made
+// with it, and restores the original context afterwards. A handler that
+// overruns its timeout returns a maxigobot.BotError wrapping
+// maxigobot.ErrHandlerTimeout.
+//
+// To limit every handler, use maxigobot.WithHandlerTimeout instead.
+func Timeout(timeout time.Duration) maxigobot.MiddlewareFunc {
+	cfg := DefaultTimeoutConfig
+	cfg.Timeout = timeout
+	return TimeoutWithConfig(cfg)
+}
+
+// TimeoutWithConfig returns a Timeout middleware with custom config.
+func TimeoutWithConfig(cfg TimeoutConfig) maxigobot.MiddlewareFunc {
+	if cfg.Skipper == nil {
+		cfg.Skipper = DefaultTimeoutConfig.Skipper
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeoutConfig.Timeout
+	}
+
+	return func(next maxigobot.HandlerFunc) maxigobot.HandlerFunc {
+		return func(c maxigobot.Context) error {
+			if cfg.Skipper(c) {
+				return next(c)
+			}
+
+			parent := c.Ctx()
+			ctx, cancel := gocontext.WithTimeout(parent, cfg.Timeout)
+			defer cancel()
+			c.SetCtx(ctx)
+			defer c.SetCtx(parent)
+
+			return maxigobot.TimeoutError(ctx, parent, next(c))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	gocontext "context"
+	"errors"
+	"testing"
+	"time"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+func TestTimeout_expires(t *testing.T) {
+	c := &mockContext{}
+	handler := Timeout(10 * time.Millisecond)(func(c maxigobot.Context) error {
+		<-c.Ctx().Done()
+		return c.Ctx().Err()
+	})
+
+	err := handler(c)
+	if !errors.Is(err, maxigobot.ErrHandlerTimeout) {
+		t.Fatalf("error = %v, want ErrHandlerTimeout", err)
+	}
+	var botErr *maxigobot.BotError
+	if !errors.As(err, &botErr) {
+		t.Errorf("error %T is not a *BotError", err)
+	}
+	if !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Errorf("error = %v, want the handler error wrapped", err)
+	}
+	if c.Ctx().Err() != nil {
+		t.Error("original context was not restored")
+	}
+}
+
+func TestTimeout_inTime(t *testing.T) {
+	var deadline time.Time
+	handler := Timeout(time.Minute)(func(c maxigobot.Context) error {
+		deadline, _ = c.Ctx().Deadline()
+		return nil
+	})
+
+	if err := handler(&mockContext{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if time.Until(deadline) < 50*time.Second {
+		t.Errorf("deadline = %v, want about a minute from now", deadline)
+	}
+}
+
+func TestTimeout_propagatesError(t *testing.T) {
+	want := "handler error"
+	handler := Timeout(time.Minute)(func(c maxigobot.Context) error {
+		return errForTest(want)
+	})
+
+	if err := handler(&mockContext{}); err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestTimeout_parentCanceled(t *testing.T) {
+	parent, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	handler := Timeout(time.Minute)(func(c maxigobot.Context) error {
+		return c.Ctx().Err()
+	})
+
+	if err := handler(&mockContext{ctx: parent}); errors.Is(err, maxigobot.ErrHandlerTimeout) {
+		t.Errorf("error = %v, want no timeout for a canceled parent", err)
+	}
+}
+
+func TestTimeoutWithConfig_skipper(t *testing.T) {
+	handler := TimeoutWithConfig(TimeoutConfig{
+		Skipper: func(c maxigobot.Context) bool { return true },
+		Timeout: time.Nanosecond,
+	})(func(c maxigobot.Context) error {
+		if _, ok := c.Ctx().Deadline(); ok {
+			t.Error("skipped middleware set a deadline")
+		}
+		return nil
+	})
+
+	if err := handler(&mockContext{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -60,49 +60,99 @@ func WithUploadRetryIntervals(intervals ...time.Duration) Option {
 	}
 }
 
-// sendConfig holds parameters for a send/reply/edit operation.
-type sendConfig struct {
-	ReplyTo            string
-	Notify             *bool
-	Format             *maxigo.TextFormat
-	Attachments        []maxigo.AttachmentRequest
+// WithWorkers limits update processing to a pool of n worker goroutines.
+// When all workers are busy, updates accumulate in the dispatch queue
+// (see [WithQueueSize]); once it is full the poller blocks, which
+// propagates backpressure to the update source.
+// Default: 0 — every update is handled in its own goroutine.
+func WithWorkers(n int) Option {
+	return func(b *Bot) {
+		b.workers = n
+	}
+}
+
+// WithQueueSize sets the capacity of the queue between the poller and the
+// handlers. Default: 100.
+func WithQueueSize(n int) Option {
+	return func(b *Bot) {
+		b.queueSize = n
+	}
+}
+
+// WithOrdering makes the bot handle related updates sequentially, e.g.
+// OrderByChat guarantees that two messages from the same chat are never
+// processed concurrently or out of order. Unrelated updates are still
+// processed in parallel by a pool of workers (see [WithWorkers]; default 32).
+// Works with any [Poller].
+func WithOrdering(o Ordering) Option {
+	return func(b *Bot) {
+		b.ordering = o
+	}
+}
+
+// SendConfig holds parameters for a send/reply/edit operation,
+// as set by SendOptions. Use ApplySendOptions to inspect the options
+// passed to a Context, e.g. in a test double.
+type SendConfig struct {
+	// ReplyTo is the message ID to reply to (WithReplyTo).
+	ReplyTo string
+	// Notify controls notifications; nil means the API default (WithNotify).
+	Notify *bool
+	// Format is the text format; nil means plain text (WithFormat).
+	Format *maxigo.TextFormat
+	// Attachments are the attachments (WithAttachments, WithKeyboard).
+	Attachments []maxigo.AttachmentRequest
+	// Media are files to upload and attach (WithMedia).
+	Media []Media
+	// DisableLinkPreview disables link previews (WithDisableLinkPreview).
 	DisableLinkPreview bool
+	// DeleteAfter is the delay before the sent message is deleted (WithDeleteAfter).
+	DeleteAfter time.Duration
 }
 
 // SendOption configures a send/reply/edit operation.
-type SendOption func(*sendConfig)
+type SendOption func(*SendConfig)
 
 // WithReplyTo sets the message ID to reply to.
 func WithReplyTo(messageID string) SendOption {
-	return func(cfg *sendConfig) {
+	return func(cfg *SendConfig) {
 		cfg.ReplyTo = messageID
 	}
 }
 
 // WithNotify controls whether chat members are notified.
 func WithNotify(notify bool) SendOption {
-	return func(cfg *sendConfig) {
+	return func(cfg *SendConfig) {
 		cfg.Notify = &notify
 	}
 }
 
 // WithFormat sets the text formatting mode (markdown or html).
 func WithFormat(format maxigo.TextFormat) SendOption {
-	return func(cfg *sendConfig) {
+	return func(cfg *SendConfig) {
 		cfg.Format = &format
 	}
 }
 
 // WithAttachments adds attachments to the message.
 func WithAttachments(attachments ...maxigo.AttachmentRequest) SendOption {
-	return func(cfg *sendConfig) {
+	return func(cfg *SendConfig) {
 		cfg.Attachments = append(cfg.Attachments, attachments...)
 	}
 }
 
+// WithMedia attaches media to the message. Files are uploaded when the
+// message is sent, unless their token is already known (see WithFileCache).
+// Media are placed before other attachments.
+func WithMedia(media ...Media) SendOption {
+	return func(cfg *SendConfig) {
+		cfg.Media = append(cfg.Media, media...)
+	}
+}
+
 // WithDisableLinkPreview prevents the server from generating link previews.
 func WithDisableLinkPreview() SendOption {
-	return func(cfg *sendConfig) {
+	return func(cfg *SendConfig) {
 		cfg.DisableLinkPreview = true
 	}
 }
@@ -113,17 +163,22 @@ func WithKeyboard(rows ...[]maxigo.Button) SendOption {
 	return WithAttachments(maxigo.NewInlineKeyboardAttachment(rows))
 }
 
-// buildSendConfig merges all send options into a sendConfig.
-func buildSendConfig(opts []SendOption) sendConfig {
-	var cfg sendConfig
+// ApplySendOptions returns the SendConfig built from opts.
+func ApplySendOptions(opts ...SendOption) SendConfig {
+	return buildSendConfig(opts)
+}
+
+// buildSendConfig merges all send options into a SendConfig.
+func buildSendConfig(opts []SendOption) SendConfig {
+	var cfg SendConfig
 	for _, opt := range opts {
 		opt(&cfg)
 	}
 	return cfg
 }
 
-// toMessageBody converts text + sendConfig into a maxigo NewMessageBody.
-func toMessageBody(text string, cfg sendConfig) *maxigo.NewMessageBody {
+// toMessageBody converts text + SendConfig into a maxigo NewMessageBody.
+func toMessageBody(text string, cfg SendConfig) *maxigo.NewMessageBody {
 	body := &maxigo.NewMessageBody{
 		Text:               maxigo.Some(text),
 		DisableLinkPreview: cfg.DisableLinkPreview,
@@ -47,7 +47,7 @@ func TestBuildSendConfig_allOptions(t *testing.T) {
 }
 
 func TestToMessageBody(t *testing.T) {
-	cfg := sendConfig{
+	cfg := SendConfig{
 		ReplyTo: "mid456",
 		Format:  ptr(maxigo.FormatHTML),
 	}
@@ -72,7 +72,7 @@ func TestToMessageBody(t *testing.T) {
 }
 
 func TestToMessageBody_noReply(t *testing.T) {
-	body := toMessageBody("test", sendConfig{})
+	body := toMessageBody("test", SendConfig{})
 	if body.Link != nil {
 		t.Error("Link should be nil when no reply")
 	}
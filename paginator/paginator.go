@@ -0,0 +1,230 @@
+// Package paginator renders long lists as pages of inline buttons with
+// "previous/next" navigation.
+//
+// The current page is carried in the navigation callback payload, so no
+// server-side storage is needed:
+//
+//	products := paginator.New("products", func(c maxigobot.Context, offset, limit int) ([]maxigo.Button, int, error) {
+//		items, total, err := db.Products(c.Ctx(), offset, limit)
+//		if err != nil {
+//			return nil, 0, err
+//		}
+//		buttons := make([]maxigo.Button, len(items))
+//		for i, it := range items {
+//			buttons[i] = maxigo.NewCallbackButton(it.Name, "product:"+it.ID)
+//		}
+//		return buttons, total, nil
+//	}, paginator.WithPerPage(8), paginator.WithColumns(2))
+//
+//	products.Register(b)
+//	b.Handle("/catalog", func(c maxigobot.Context) error {
+//		return products.Send(c, 0)
+//	})
+//
+// Navigation edits the message in place. Callbacks are not answered by the
+// paginator; use middleware.AutoRespond to remove the loading state.
+package paginator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+const (
+	// DefaultPerPage is the default number of items per page.
+	DefaultPerPage = 10
+	// DefaultColumns is the default number of item buttons per row.
+	DefaultColumns = 1
+)
+
+// ErrInvalidPage is returned when a navigation callback carries no valid page number.
+var ErrInvalidPage = errors.New("paginator: invalid page")
+
+// Source returns the buttons for items [offset, offset+limit) and the total
+// number of items.
+type Source func(c maxigobot.Context, offset, limit int) (buttons []maxigo.Button, total int, err error)
+
+// Registrar registers handlers. Both *maxigobot.Bot and *maxigobot.Group implement it.
+type Registrar interface {
+	Handle(endpoint any, h maxigobot.HandlerFunc, m ...maxigobot.MiddlewareFunc)
+}
+
+// Option configures a Paginator.
+type Option func(*Paginator)
+
+// WithPerPage sets the number of items per page. Default: DefaultPerPage.
+func WithPerPage(n int) Option {
+	return func(p *Paginator) {
+		if n > 0 {
+			p.perPage = n
+		}
+	}
+}
+
+// WithColumns sets the number of item buttons per row. Default: DefaultColumns.
+func WithColumns(n int) Option {
+	return func(p *Paginator) {
+		if n > 0 {
+			p.cols = n
+		}
+	}
+}
+
+// WithText sets the message text for a page. page is zero-based.
+// Default: "Page N of M".
+func WithText(fn func(page, pages int) string) Option {
+	return func(p *Paginator) {
+		p.text = fn
+	}
+}
+
+// WithNavText sets the labels of the previous and next page buttons.
+func WithNavText(prev, next string) Option {
+	return func(p *Paginator) {
+		p.prevText = prev
+		p.nextText = next
+	}
+}
+
+// WithSeparator sets the callback separator used in navigation payloads.
+// It must match maxigobot.WithCallbackSeparator.
+// Default: maxigobot.DefaultCallbackSeparator.
+func WithSeparator(sep string) Option {
+	return func(p *Paginator) {
+		p.sep = sep
+	}
+}
+
+// Paginator renders pages of a Source. It is safe for concurrent use.
+type Paginator struct {
+	unique   string
+	source   Source
+	perPage  int
+	cols     int
+	text     func(page, pages int) string
+	prevText string
+	nextText string
+	sep      string
+}
+
+// New creates a paginator. unique identifies its navigation callbacks
+// and must not be used by other callback handlers.
+func New(unique string, source Source, opts ...Option) *Paginator {
+	p := &Paginator{
+		unique:   unique,
+		source:   source,
+		perPage:  DefaultPerPage,
+		cols:     DefaultColumns,
+		text:     defaultText,
+		prevText: "«",
+		nextText: "»",
+		sep:      maxigobot.DefaultCallbackSeparator,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Endpoint returns the endpoint of the navigation callbacks.
+func (p *Paginator) Endpoint() string { return maxigobot.OnCallback(p.unique) }
+
+// Register registers the navigation handler on r with optional middleware.
+func (p *Paginator) Register(r Registrar, m ...maxigobot.MiddlewareFunc) {
+	r.Handle(p.Endpoint(), p.handle, m...)
+}
+
+// Page renders the given zero-based page. Out-of-range pages are clamped.
+func (p *Paginator) Page(c maxigobot.Context, page int) (string, maxigobot.Keyboard, error) {
+	// Clamp before multiplying so that the offset cannot overflow.
+	page = min(max(page, 0), math.MaxInt/p.perPage)
+	buttons, total, err := p.source(c, page*p.perPage, p.perPage)
+	if err != nil {
+		return "", maxigobot.Keyboard{}, err
+	}
+	pages := max((total+p.perPage-1)/p.perPage, 1)
+	if page >= pages {
+		page = pages - 1
+		if buttons, total, err = p.source(c, page*p.perPage, p.perPage); err != nil {
+			return "", maxigobot.Keyboard{}, err
+		}
+		pages = max((total+p.perPage-1)/p.perPage, 1)
+	}
+
+	var kb maxigobot.Keyboard
+	kb = kb.Grid(buttons, p.cols)
+	if pages > 1 {
+		var nav []maxigo.Button
+		if page > 0 {
+			nav = append(nav, kb.Callback(p.prevText, p.payload(page-1)))
+		}
+		nav = append(nav, kb.Callback(fmt.Sprintf("%d/%d", page+1, pages), p.payload(page)))
+		if page < pages-1 {
+			nav = append(nav, kb.Callback(p.nextText, p.payload(page+1)))
+		}
+		kb = kb.Row(nav...)
+	}
+	return p.text(page, pages), kb, nil
+}
+
+// Send sends a new message with the given page to the current chat.
+func (p *Paginator) Send(c maxigobot.Context, page int) error {
+	text, kb, err := p.Page(c, page)
+	if err != nil {
+		return err
+	}
+	opts, err := keyboardOptions(kb)
+	if err != nil {
+		return err
+	}
+	return c.Send(text, opts...)
+}
+
+// handle edits the callback message to show the requested page.
+func (p *Paginator) handle(c maxigobot.Context) error {
+	args := c.CallbackArgs()
+	if len(args) != 1 {
+		return ErrInvalidPage
+	}
+	page, err := strconv.Atoi(args[0])
+	if err != nil || page > math.MaxInt/p.perPage {
+		return ErrInvalidPage
+	}
+
+	text, kb, err := p.Page(c, page)
+	if err != nil {
+		return err
+	}
+	opts, err := keyboardOptions(kb)
+	if err != nil {
+		return err
+	}
+	return c.Edit(text, opts...)
+}
+
+// keyboardOptions attaches kb to the message. An empty list is sent
+// without a keyboard.
+func keyboardOptions(kb maxigobot.Keyboard) ([]maxigobot.SendOption, error) {
+	if len(kb.Rows()) == 0 {
+		return nil, nil
+	}
+	att, err := kb.Attachment()
+	if err != nil {
+		return nil, err
+	}
+	return []maxigobot.SendOption{maxigobot.WithAttachments(att)}, nil
+}
+
+func (p *Paginator) payload(page int) string {
+	return p.unique + p.sep + strconv.Itoa(page)
+}
+
+func defaultText(page, pages int) string {
+	return fmt.Sprintf("Page %d of %d", page+1, pages)
+}
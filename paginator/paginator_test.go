@@ -0,0 +1,166 @@
+package paginator
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+
+	maxigobot "github.com/maxigo-bot/maxigo-bot"
+)
+
+// pageContext is a minimal Context stub that records sent and edited messages.
+type pageContext struct {
+	maxigobot.Context
+	args   []string
+	sent   string
+	edited string
+	opts   []maxigobot.SendOption
+}
+
+func (c *pageContext) CallbackArgs() []string { return c.args }
+
+func (c *pageContext) Send(text string, opts ...maxigobot.SendOption) error {
+	c.sent, c.opts = text, opts
+	return nil
+}
+
+func (c *pageContext) Edit(text string, opts ...maxigobot.SendOption) error {
+	c.edited, c.opts = text, opts
+	return nil
+}
+
+// registrar records registered endpoints.
+type registrar map[string]maxigobot.HandlerFunc
+
+func (r registrar) Handle(endpoint any, h maxigobot.HandlerFunc, _ ...maxigobot.MiddlewareFunc) {
+	r[endpoint.(string)] = h
+}
+
+func numbers(total int) Source {
+	return func(_ maxigobot.Context, offset, limit int) ([]maxigo.Button, int, error) {
+		var buttons []maxigo.Button
+		for i := offset; i < min(offset+limit, total); i++ {
+			buttons = append(buttons, maxigo.NewCallbackButton(strconv.Itoa(i), "n:"+strconv.Itoa(i)))
+		}
+		return buttons, total, nil
+	}
+}
+
+func navTexts(kb maxigobot.Keyboard) []string {
+	rows := kb.Rows()
+	var texts []string
+	for _, btn := range rows[len(rows)-1] {
+		texts = append(texts, btn.Text+"="+btn.Payload)
+	}
+	return texts
+}
+
+func TestPaginator_Page(t *testing.T) {
+	p := New("nums", numbers(25), WithPerPage(10), WithColumns(5))
+
+	tests := []struct {
+		page     int
+		wantText string
+		wantRows int
+		wantNav  []string
+	}{
+		{0, "Page 1 of 3", 3, []string{"1/3=nums:0", "»=nums:1"}},
+		{1, "Page 2 of 3", 3, []string{"«=nums:0", "2/3=nums:1", "»=nums:2"}},
+		{2, "Page 3 of 3", 2, []string{"«=nums:1", "3/3=nums:2"}},
+		{9, "Page 3 of 3", 2, []string{"«=nums:1", "3/3=nums:2"}},
+		{-1, "Page 1 of 3", 3, []string{"1/3=nums:0", "»=nums:1"}},
+		{math.MaxInt, "Page 3 of 3", 2, []string{"«=nums:1", "3/3=nums:2"}},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.page), func(t *testing.T) {
+			text, kb, err := p.Page(&pageContext{}, tt.page)
+			if err != nil {
+				t.Fatalf("Page: %v", err)
+			}
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if got := len(kb.Rows()); got != tt.wantRows {
+				t.Errorf("rows = %d, want %d", got, tt.wantRows)
+			}
+			nav := navTexts(kb)
+			if len(nav) != len(tt.wantNav) {
+				t.Fatalf("nav = %q, want %q", nav, tt.wantNav)
+			}
+			for i := range nav {
+				if nav[i] != tt.wantNav[i] {
+					t.Errorf("nav = %q, want %q", nav, tt.wantNav)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestPaginator_singlePage(t *testing.T) {
+	p := New("nums", numbers(3))
+	_, kb, err := p.Page(&pageContext{}, 0)
+	if err != nil {
+		t.Fatalf("Page: %v", err)
+	}
+	if got := len(kb.Rows()); got != 3 {
+		t.Errorf("rows = %d, want 3 without navigation", got)
+	}
+}
+
+func TestPaginator_Send(t *testing.T) {
+	p := New("nums", numbers(0), WithText(func(page, pages int) string { return "empty" }))
+	c := &pageContext{}
+	if err := p.Send(c, 0); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if c.sent != "empty" || len(c.opts) != 0 {
+		t.Errorf("sent %q with %d options, want empty list without keyboard", c.sent, len(c.opts))
+	}
+
+	p = New("nums", numbers(15))
+	if err := p.Send(c, 1); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if c.sent != "Page 2 of 2" || len(c.opts) != 1 {
+		t.Errorf("sent %q with %d options", c.sent, len(c.opts))
+	}
+}
+
+func TestPaginator_Register(t *testing.T) {
+	p := New("nums", numbers(30), WithNavText("prev", "next"))
+	r := registrar{}
+	p.Register(r)
+
+	h, ok := r[maxigobot.OnCallback("nums")]
+	if !ok {
+		t.Fatal("navigation handler not registered")
+	}
+
+	c := &pageContext{args: []string{"2"}}
+	if err := h(c); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if c.edited != "Page 3 of 3" {
+		t.Errorf("edited = %q, want %q", c.edited, "Page 3 of 3")
+	}
+
+	for _, args := range [][]string{nil, {"x"}, {"1", "2"}, {strconv.Itoa(math.MaxInt / 5)}} {
+		if err := h(&pageContext{args: args}); !errors.Is(err, ErrInvalidPage) {
+			t.Errorf("handler with args %q = %v, want ErrInvalidPage", args, err)
+		}
+	}
+}
+
+func TestPaginator_sourceError(t *testing.T) {
+	errDB := errors.New("db down")
+	p := New("nums", func(maxigobot.Context, int, int) ([]maxigo.Button, int, error) {
+		return nil, 0, errDB
+	})
+	if err := p.Send(&pageContext{}, 0); !errors.Is(err, errDB) {
+		t.Errorf("Send = %v, want %v", err, errDB)
+	}
+}
@@ -0,0 +1,132 @@
+package maxigobot
+
+import (
+	"regexp"
+	"strings"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// TextMatcher matches the text of messages for pattern-based routing.
+// Pass a TextMatcher (or a *regexp.Regexp) to Handle:
+//
+//	b.Handle(regexp.MustCompile(`^order #(\d+)$`), func(c maxigobot.Context) error {
+//		return c.Send("Order " + c.Match()[1])
+//	})
+//	b.Handle(maxigobot.Prefix("echo "), echoHandler)
+//	b.Handle(maxigobot.Glob("buy * for *"), buyHandler)
+//
+// Patterns are tried in registration order (groups first, like exact
+// endpoints) before the OnText handler. This includes media messages with a
+// caption that no attachment handler accepted. If no pattern matches, or
+// every matching handler declines the update with ErrSkip, routing continues
+// with the usual OnText → OnMessage fallback chain.
+type TextMatcher interface {
+	// MatchText reports whether text matches. On success it returns the
+	// match: the full text at index 0 followed by the captured parts.
+	MatchText(text string) ([]string, bool)
+}
+
+// Prefix returns a TextMatcher for messages starting with prefix.
+// The captured part is the rest of the text after the prefix.
+func Prefix(prefix string) TextMatcher {
+	return prefixMatcher(prefix)
+}
+
+type prefixMatcher string
+
+func (p prefixMatcher) MatchText(text string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(text, string(p))
+	if !ok {
+		return nil, false
+	}
+	return []string{text, rest}, true
+}
+
+// Glob returns a TextMatcher for a shell-style pattern matched against the
+// whole text: '*' matches any sequence of characters and '?' matches a
+// single character. Each wildcard is captured; '*' is matched lazily, so
+// earlier wildcards capture as little as possible.
+//
+// The pattern is compiled to a regular expression, so matching takes time
+// linear in the length of the text.
+func Glob(pattern string) TextMatcher {
+	var expr strings.Builder
+	expr.WriteString(`(?s)^`)
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(`(.*?)`)
+		case '?':
+			expr.WriteString(`(.)`)
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString(`$`)
+	return regexpMatcher{re: regexp.MustCompile(expr.String())}
+}
+
+type regexpMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m regexpMatcher) MatchText(text string) ([]string, bool) {
+	match := m.re.FindStringSubmatch(text)
+	return match, match != nil
+}
+
+// patternEntry is a handler registered for a TextMatcher.
+type patternEntry struct {
+	matcher TextMatcher
+	entry   *handlerEntry
+}
+
+// textMatcher converts a pattern endpoint to a TextMatcher.
+func textMatcher(endpoint any) (TextMatcher, bool) {
+	switch e := endpoint.(type) {
+	case *regexp.Regexp:
+		return regexpMatcher{re: e}, true
+	case TextMatcher:
+		return e, true
+	default:
+		return nil, false
+	}
+}
+
+// addPattern registers a pattern handler in registration order.
+func addPattern(patterns []*patternEntry, m TextMatcher, h HandlerFunc, mw []MiddlewareFunc) []*patternEntry {
+	return append(patterns, &patternEntry{
+		matcher: m,
+		entry:   &handlerEntry{handler: h, middleware: mw},
+	})
+}
+
+// findPatterns returns the pattern handlers matching text in registration
+// order, searching groups first and then bot handlers.
+func (b *Bot) findPatterns(text string) []routeCandidate {
+	var cands []routeCandidate
+	for _, g := range b.groups {
+		for _, p := range g.patterns {
+			if match, ok := p.matcher.MatchText(text); ok {
+				cands = append(cands, routeCandidate{entry: p.entry, groupMW: g.middleware, match: match})
+			}
+		}
+	}
+	for _, p := range b.patterns {
+		if match, ok := p.matcher.MatchText(text); ok {
+			cands = append(cands, routeCandidate{entry: p.entry, match: match})
+		}
+	}
+	return cands
+}
+
+// textPatterns returns the pattern handlers matching the text or caption of
+// msg, with leading bot mentions stripped in group chats.
+func (b *Bot) textPatterns(msg *maxigo.Message) []routeCandidate {
+	if msg.Body.Text == nil {
+		return nil
+	}
+	text, _ := StripBotMention(*msg.Body.Text, msg.Recipient.ChatType)
+	return b.findPatterns(text)
+}
@@ -0,0 +1,236 @@
+package maxigobot
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+func TestTextMatchers(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher TextMatcher
+		text    string
+		want    []string
+	}{
+		{"prefix", Prefix("echo "), "echo hi there", []string{"echo hi there", "hi there"}},
+		{"prefix miss", Prefix("echo "), "say hi", nil},
+		{"glob star", Glob("buy * for *"), "buy milk for 2", []string{"buy milk for 2", "milk", "2"}},
+		{"glob question", Glob("v?"), "v2", []string{"v2", "2"}},
+		{"glob unicode", Glob("привет *"), "привет мир", []string{"привет мир", "мир"}},
+		{"glob whole text", Glob("hi"), "hi there", nil},
+		{"glob lazy", Glob("* *"), "a b c", []string{"a b c", "a", "b c"}},
+		{"glob literal metacharacters", Glob("1+1=(?)"), "1+1=(2)", []string{"1+1=(2)", "2"}},
+		{"glob multiline", Glob("note: *"), "note: a\nb", []string{"note: a\nb", "a\nb"}},
+		{"regexp", regexpMatcher{regexp.MustCompile(`^order #(\d+)$`)}, "order #42", []string{"order #42", "42"}},
+		{"regexp miss", regexpMatcher{regexp.MustCompile(`^order #(\d+)$`)}, "order 42", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.matcher.MatchText(tt.text)
+			if ok != (tt.want != nil) {
+				t.Fatalf("ok = %v, want %v", ok, tt.want != nil)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("match = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("match[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGlob_linearTime(t *testing.T) {
+	m := Glob(strings.Repeat("*a", 20) + "b")
+	text := strings.Repeat("a", 10000)
+
+	start := time.Now()
+	if _, ok := m.MatchText(text); ok {
+		t.Error("pattern should not match")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("matching took %v", elapsed)
+	}
+}
+
+func TestBot_ProcessUpdate_regexpRouting(t *testing.T) {
+	b, _ := New("token")
+
+	var got []string
+	b.Handle(regexp.MustCompile(`^order #(\d+)$`), func(c Context) error {
+		got = append(got, "order:"+c.Match()[1])
+		return nil
+	})
+	b.Handle(Prefix("order"), func(c Context) error {
+		got = append(got, "prefix")
+		return nil
+	})
+	b.Handle(OnText, func(c Context) error {
+		if c.Match() != nil {
+			t.Error("Match() should be nil for OnText")
+		}
+		got = append(got, "text")
+		return nil
+	})
+
+	b.processUpdate(textUpdate(1, 1, "order #42"))
+	b.processUpdate(textUpdate(1, 1, "order 42"))
+	b.processUpdate(textUpdate(1, 1, "hello"))
+
+	want := []string{"order:42", "prefix", "text"}
+	if len(got) != len(want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("calls[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBot_ProcessUpdate_patternMatchesCaption(t *testing.T) {
+	b, _ := New("token")
+
+	var got []string
+	b.Handle(Prefix("invoice "), func(c Context) error {
+		got = append(got, "pattern:"+c.Match()[1])
+		return nil
+	})
+	b.Handle(OnText, func(c Context) error {
+		got = append(got, "text")
+		return nil
+	})
+
+	photo := func(caption string) *maxigo.MessageCreatedUpdate {
+		u := attachmentUpdate(`{"type":"image"}`)
+		u.Message.Body.Text = ptrString(caption)
+		return u
+	}
+	b.processUpdate(photo("invoice 42"))
+	b.processUpdate(photo("hello"))
+
+	b.Handle(OnPhoto, func(c Context) error {
+		got = append(got, "photo")
+		return nil
+	})
+	b.processUpdate(photo("invoice 43"))
+
+	want := []string{"pattern:42", "text", "photo"}
+	if len(got) != len(want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("calls[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBot_ProcessUpdate_patternNotUsedForCommands(t *testing.T) {
+	b, _ := New("token")
+
+	called := false
+	b.Handle(Prefix("/"), func(c Context) error {
+		called = true
+		return nil
+	})
+
+	b.processUpdate(textUpdate(1, 1, "/start"))
+	if called {
+		t.Error("patterns should only match OnText messages")
+	}
+}
+
+func TestBot_ProcessUpdate_groupPattern(t *testing.T) {
+	b, _ := New("token")
+
+	var order []string
+	g := b.Group()
+	g.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			order = append(order, "group")
+			return next(c)
+		}
+	})
+	g.Handle(Glob("hi *"), func(c Context) error {
+		order = append(order, "group:"+c.Match()[1])
+		return nil
+	})
+	b.Handle(Glob("hi *"), func(c Context) error {
+		order = append(order, "bot")
+		return nil
+	})
+
+	b.processUpdate(&maxigo.MessageCreatedUpdate{Message: maxigo.Message{
+		Recipient: maxigo.Recipient{ChatType: maxigo.ChatGroup},
+		Body:      maxigo.MessageBody{Text: ptrString("@bot hi all")},
+	}})
+
+	if len(order) != 2 || order[0] != "group" || order[1] != "group:all" {
+		t.Errorf("order = %v, want [group group:all]", order)
+	}
+}
+
+func TestBot_ProcessUpdate_patternSkipFallsBack(t *testing.T) {
+	b, _ := New("token", WithStateStorage(&mapStateStorage{}))
+
+	var got []string
+	b.HandleFiltered(regexp.MustCompile(`^order #(\d+)$`), inState("await_order"), func(c Context) error {
+		got = append(got, "regexp")
+		return nil
+	})
+	b.Handle(OnText, func(c Context) error {
+		if c.Match() != nil {
+			t.Errorf("Match() = %q, want nil for OnText", c.Match())
+		}
+		got = append(got, "text")
+		return nil
+	})
+
+	b.processUpdate(textUpdate(1, 2, "order #42"))
+
+	if len(got) != 1 || got[0] != "text" {
+		t.Errorf("calls = %v, want [text]", got)
+	}
+}
+
+func TestBot_ProcessUpdate_skipFallbackChain(t *testing.T) {
+	b, _ := New("token")
+
+	var got []string
+	skip := func(name string) HandlerFunc {
+		return func(c Context) error {
+			got = append(got, name)
+			return ErrSkip
+		}
+	}
+	b.Handle(Prefix("hi"), skip("prefix"))
+	b.Handle(Glob("hi *"), func(c Context) error {
+		got = append(got, "glob:"+c.Match()[1])
+		return ErrSkip
+	})
+	b.Handle(OnText, skip("text"))
+	b.Handle(OnMessage, func(c Context) error {
+		got = append(got, "message")
+		return nil
+	})
+
+	b.processUpdate(textUpdate(1, 1, "hi all"))
+
+	want := []string{"prefix", "glob:all", "text", "message"}
+	if len(got) != len(want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("calls[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
@@ -29,6 +29,8 @@ type LongPoller struct {
 	Timeout int
 	// UpdateTypes filters which update types to receive. Empty means all.
 	UpdateTypes []string
+	// Recorder, if set, records the raw JSON of every received update.
+	Recorder *UpdateRecorder
 }
 
 // Poll starts the long-polling loop.
@@ -102,6 +104,11 @@ func (p *LongPoller) Poll(b *Bot, updates chan<- any, stop chan struct{}) {
 		backoff = initialBackoff // Reset on success.
 
 		for _, raw := range list.Updates {
+			if p.Recorder != nil {
+				if err := p.Recorder.Record(raw); err != nil {
+					b.handleError(fmt.Errorf("record update error: %w", err), nil, "poller")
+				}
+			}
 			upd, err := ParseUpdate(raw)
 			if err != nil {
 				b.handleError(fmt.Errorf("parse update error: %w", err), nil, "poller")
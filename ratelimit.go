@@ -0,0 +1,194 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// chatSweepMin is the number of per-chat buckets kept before idle ones are swept.
+const chatSweepMin = 1024
+
+// WithRateLimit limits outgoing API calls made through Context (Send, Edit,
+// Delete, Respond, ...) to perSecond requests per second across all chats,
+// allowing bursts of up to burst requests. Calls over the limit block until
+// a token is available or c.Ctx() is done, instead of failing with HTTP 429.
+// Default: no limit.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(b *Bot) {
+		b.limiter().global = newTokenBucket(perSecond, burst)
+	}
+}
+
+// WithChatRateLimit additionally limits outgoing API calls to perSecond
+// requests per second within a single chat, with bursts of up to burst.
+// It can be combined with WithRateLimit. Default: no limit.
+func WithChatRateLimit(perSecond float64, burst int) Option {
+	return func(b *Bot) {
+		l := b.limiter()
+		l.chatRate = perSecond
+		l.chatBurst = burst
+	}
+}
+
+// limiter returns the bot's rate limiter, creating it if needed.
+func (b *Bot) limiter() *rateLimiter {
+	if b.limit == nil {
+		b.limit = &rateLimiter{now: time.Now}
+	}
+	return b.limit
+}
+
+// call runs an API request with proactive rate limiting and retries.
+// The limiter is consulted before every attempt, including retries.
+func (b *Bot) call(ctx gocontext.Context, chatID int64, fn func() error) error {
+	return withRetry(ctx, b.retry, func() error {
+		if err := b.limit.wait(ctx, chatID); err != nil {
+			return err
+		}
+		return fn()
+	})
+}
+
+// rateLimiter combines a global token bucket with per-chat buckets.
+type rateLimiter struct {
+	global    *tokenBucket
+	chatRate  float64
+	chatBurst int
+
+	mu        sync.Mutex
+	chats     map[int64]*tokenBucket
+	nextSweep int
+
+	waiting atomic.Int64
+	now     func() time.Time
+}
+
+// wait blocks until both the chat and the global bucket allow a request.
+// If ctx is done first, no token is consumed. Safe to call on a nil limiter.
+func (l *rateLimiter) wait(ctx gocontext.Context, chatID int64) error {
+	if l == nil {
+		return nil
+	}
+	chat := l.chat(chatID)
+	if chat != nil {
+		if err := l.take(ctx, chat); err != nil {
+			return err
+		}
+	}
+	if l.global != nil {
+		if err := l.take(ctx, l.global); err != nil {
+			// The request is not sent, so give back the chat token too.
+			if chat != nil {
+				chat.release()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// take reserves a token from tb and sleeps until it becomes available.
+// The reservation is released if ctx is done first.
+func (l *rateLimiter) take(ctx gocontext.Context, tb *tokenBucket) error {
+	d := tb.reserve(l.now())
+	if d <= 0 {
+		return nil
+	}
+
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		tb.release()
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// chat returns the bucket of a chat, or nil if per-chat limiting is off.
+// Buckets that have refilled completely are swept as the map grows.
+func (l *rateLimiter) chat(chatID int64) *tokenBucket {
+	if l.chatRate <= 0 || chatID == 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if tb, ok := l.chats[chatID]; ok {
+		return tb
+	}
+	if l.chats == nil {
+		l.chats = make(map[int64]*tokenBucket)
+	}
+	if len(l.chats) >= max(l.nextSweep, chatSweepMin) {
+		now := l.now()
+		for id, tb := range l.chats {
+			if tb.idle(now) {
+				delete(l.chats, id)
+			}
+		}
+		l.nextSweep = 2 * len(l.chats)
+	}
+	tb := newTokenBucket(l.chatRate, l.chatBurst)
+	l.chats[chatID] = tb
+	return tb
+}
+
+// tokenBucket is a token bucket that hands out reservations: tokens may go
+// negative, and the deficit determines how long the caller must wait.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(perSecond float64, burst int) *tokenBucket {
+	if perSecond <= 0 {
+		return nil
+	}
+	b := float64(max(burst, 1))
+	return &tokenBucket{rate: perSecond, burst: b, tokens: b}
+}
+
+// refill adds tokens accrued since the last call. Caller must hold mu.
+func (tb *tokenBucket) refill(now time.Time) {
+	if !tb.last.IsZero() {
+		tb.tokens = min(tb.burst, tb.tokens+now.Sub(tb.last).Seconds()*tb.rate)
+	}
+	tb.last = now
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (tb *tokenBucket) reserve(now time.Time) time.Duration {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill(now)
+	tb.tokens--
+	if tb.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-tb.tokens / tb.rate * float64(time.Second))
+}
+
+// release returns a reserved token that was not used.
+func (tb *tokenBucket) release() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.tokens = min(tb.burst, tb.tokens+1)
+}
+
+// idle reports whether the bucket is full, i.e. dropping it loses no state.
+func (tb *tokenBucket) idle(now time.Time) bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refill(now)
+	return tb.tokens >= tb.burst
+}
@@ -0,0 +1,166 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket_reserve(t *testing.T) {
+	tb := newTokenBucket(10, 2)
+	t0 := time.Unix(0, 0)
+
+	for i, want := range []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond} {
+		if got := tb.reserve(t0); got != want {
+			t.Errorf("reserve #%d = %v, want %v", i, got, want)
+		}
+	}
+
+	// After a second the bucket is full again, but not above burst.
+	t1 := t0.Add(time.Second)
+	if !tb.idle(t1) {
+		t.Error("bucket should be idle after refilling")
+	}
+	if got := tb.reserve(t1); got != 0 {
+		t.Errorf("reserve after refill = %v, want 0", got)
+	}
+	if got := tb.reserve(t1); got != 0 {
+		t.Errorf("second reserve after refill = %v, want 0", got)
+	}
+	if got := tb.reserve(t1); got == 0 {
+		t.Error("third reserve after refill should wait")
+	}
+}
+
+func TestNewTokenBucket_disabled(t *testing.T) {
+	if tb := newTokenBucket(0, 10); tb != nil {
+		t.Error("zero rate should disable the bucket")
+	}
+}
+
+func TestRateLimiter_perChat(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := &rateLimiter{chatRate: 1, chatBurst: 1, now: func() time.Time { return now }}
+
+	if d := l.chat(1).reserve(now); d != 0 {
+		t.Errorf("chat 1 first reserve = %v, want 0", d)
+	}
+	if d := l.chat(1).reserve(now); d != time.Second {
+		t.Errorf("chat 1 second reserve = %v, want 1s", d)
+	}
+	if d := l.chat(2).reserve(now); d != 0 {
+		t.Errorf("chat 2 reserve = %v, want 0", d)
+	}
+	if l.chat(0) != nil {
+		t.Error("requests without a chat should not use a chat bucket")
+	}
+}
+
+func TestRateLimiter_sweep(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := &rateLimiter{chatRate: 1, chatBurst: 1, now: func() time.Time { return now }}
+
+	for id := int64(1); id <= chatSweepMin; id++ {
+		l.chat(id).reserve(now)
+	}
+	now = now.Add(time.Minute)
+	l.chat(-1)
+	if len(l.chats) != 1 {
+		t.Errorf("chats after sweep = %d, want 1", len(l.chats))
+	}
+}
+
+func TestRateLimiter_waitCanceled(t *testing.T) {
+	l := &rateLimiter{global: newTokenBucket(1, 1), now: time.Now}
+
+	if err := l.wait(gocontext.Background(), 1); err != nil {
+		t.Fatalf("first wait: %v", err)
+	}
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx, 1); !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Fatalf("wait = %v, want DeadlineExceeded", err)
+	}
+
+	// The canceled reservation was released, so the next caller waits
+	// for one token, not two.
+	if d := l.global.reserve(time.Now()); d > time.Second {
+		t.Errorf("reserve after cancel = %v, want <= 1s", d)
+	}
+}
+
+func TestRateLimiter_waitCanceled_releasesChatToken(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := &rateLimiter{
+		global:    newTokenBucket(1, 1),
+		chatRate:  1,
+		chatBurst: 1,
+		now:       func() time.Time { return now },
+	}
+	l.global.reserve(now) // drain the global bucket
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.wait(ctx, 1); !errors.Is(err, gocontext.DeadlineExceeded) {
+		t.Fatalf("wait = %v, want DeadlineExceeded", err)
+	}
+
+	// The request was not sent, so the chat token it took was given back.
+	if !l.chat(1).idle(now) {
+		t.Error("chat token was not released")
+	}
+}
+
+func TestRateLimiter_nil(t *testing.T) {
+	var l *rateLimiter
+	if err := l.wait(gocontext.Background(), 1); err != nil {
+		t.Errorf("nil limiter wait = %v", err)
+	}
+}
+
+func TestWithRateLimit_Send(t *testing.T) {
+	b := testBotWithServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, `{"message":{"recipient":{"chat_id":100,"chat_type":"dialog"},"timestamp":1,"body":{"mid":"m","seq":1}}}`)
+	})
+	WithRateLimit(20, 1)(b)
+	WithChatRateLimit(100, 1)(b)
+
+	ctx := newTestContext(b, textUpdate(100, 1, "hi"))
+	start := time.Now()
+	for range 3 {
+		if err := ctx.Send("hello"); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("3 sends at 20 rps took %v, want >= 100ms", elapsed)
+	}
+}
+
+func TestBot_Stats_throttled(t *testing.T) {
+	b, _ := New("token", WithRateLimit(0.1, 1))
+	_ = b.limit.wait(gocontext.Background(), 0)
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	done := make(chan error)
+	go func() { done <- b.limit.wait(ctx, 0) }()
+
+	deadline := time.Now().Add(time.Second)
+	for b.Stats().Throttled != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := b.Stats().Throttled; got != 1 {
+		t.Errorf("Throttled = %d, want 1", got)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, gocontext.Canceled) {
+		t.Errorf("wait = %v, want Canceled", err)
+	}
+	if got := b.Stats().Throttled; got != 0 {
+		t.Errorf("Throttled after cancel = %d, want 0", got)
+	}
+}
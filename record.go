@@ -0,0 +1,238 @@
+package maxigobot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// redactedValue replaces redacted string values in recorded updates.
+const redactedValue = "[redacted]"
+
+// RedactPersonalData lists the JSON keys of user-written text and contact
+// phone numbers, for use as UpdateRecorder.Redact.
+var RedactPersonalData = []string{"text", "vcf_info", "vcf_phone"}
+
+// RecordedUpdate is a line of an update recording.
+type RecordedUpdate struct {
+	// Time is when the update was received.
+	Time time.Time `json:"time"`
+	// Update is the raw update JSON, as passed to ParseUpdate.
+	Update json.RawMessage `json:"update"`
+}
+
+// UpdateRecorder writes the raw JSON of received updates to a JSONL file,
+// one RecordedUpdate per line, so that an incident can be reproduced with
+// ReplayPoller. Set it as the Recorder of a LongPoller or WebhookPoller:
+//
+//	rec, err := maxigobot.OpenUpdateRecorder("updates.jsonl")
+//	// handle err
+//	defer rec.Close()
+//	rec.Redact = maxigobot.RedactPersonalData
+//	b, err := maxigobot.New(token, maxigobot.WithPoller(&maxigobot.LongPoller{Recorder: rec}))
+//
+// Updates are recorded before parsing, including unknown update types.
+// An UpdateRecorder is safe for concurrent use.
+type UpdateRecorder struct {
+	// Redact lists JSON keys whose string values are replaced with
+	// "[redacted]" at any depth, e.g. RedactPersonalData. Redacted updates
+	// keep their structure, so they can still be replayed.
+	Redact []string
+
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewUpdateRecorder returns a recorder that writes to w.
+func NewUpdateRecorder(w io.Writer) *UpdateRecorder {
+	return &UpdateRecorder{w: w}
+}
+
+// OpenUpdateRecorder returns a recorder that appends to the file at path,
+// creating it if needed. The file may contain personal data, so it is
+// created readable by the owner only. Close the recorder when done.
+func OpenUpdateRecorder(path string) (*UpdateRecorder, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &UpdateRecorder{w: f, closer: f}, nil
+}
+
+// Record writes raw with the current time.
+func (r *UpdateRecorder) Record(raw json.RawMessage) error {
+	if len(r.Redact) > 0 {
+		var err error
+		if raw, err = redactJSON(raw, r.Redact); err != nil {
+			return fmt.Errorf("maxigobot: redact update: %w", err)
+		}
+	}
+	// Marshal compacts raw, so every update fits on one line.
+	line, err := json.Marshal(RecordedUpdate{Time: time.Now(), Update: raw})
+	if err != nil {
+		return fmt.Errorf("maxigobot: record update: %w", err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.w.Write(line)
+	return err
+}
+
+// Close closes the file opened by OpenUpdateRecorder. It does nothing for
+// recorders created by NewUpdateRecorder.
+func (r *UpdateRecorder) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// redactJSON replaces the string values of keys in raw.
+func redactJSON(raw json.RawMessage, keys []string) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber() // Keep IDs and timestamps exact.
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	redactValue(v, keys)
+	return json.Marshal(v)
+}
+
+func redactValue(v any, keys []string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if _, ok := val.(string); ok && slices.Contains(keys, k) {
+				v[k] = redactedValue
+				continue
+			}
+			redactValue(val, keys)
+		}
+	case []any:
+		for _, val := range v {
+			redactValue(val, keys)
+		}
+	}
+}
+
+// ReplayPoller implements [Poller] by reading a recording made by
+// UpdateRecorder and feeding the updates to the bot with their original
+// timing, optionally accelerated. Combined with the fake API of the
+// maxigobottest package it reproduces a production incident locally:
+//
+//	srv := maxigobottest.NewServer(t)
+//	replay := &maxigobot.ReplayPoller{Path: "updates.jsonl", Speed: 10}
+//	b, _ := srv.NewBot(t, maxigobot.WithPoller(replay))
+//	registerHandlers(b)
+//	maxigobottest.Start(t, b)
+//	<-replay.Done()
+//
+// After the last update Poll blocks until the bot is stopped, as required
+// by [Poller]. Errors are reported to Bot.OnError. A ReplayPoller replays
+// its recording once and cannot be reused for another Start.
+type ReplayPoller struct {
+	// Path is the recording file.
+	Path string
+	// Reader, if set, is read instead of Path.
+	Reader io.Reader
+	// Speed is the replay speed factor: 1 (the default) keeps the recorded
+	// delays between updates, 10 replays ten times faster. A negative Speed
+	// replays without delays.
+	Speed float64
+
+	initOnce sync.Once
+	done     chan struct{}
+}
+
+func (p *ReplayPoller) init() {
+	p.initOnce.Do(func() {
+		p.done = make(chan struct{})
+	})
+}
+
+// Done returns a channel that is closed when all recorded updates have been
+// passed to the bot, or the replay ended early because of an error or Stop.
+// Handlers may still be running.
+func (p *ReplayPoller) Done() <-chan struct{} {
+	p.init()
+	return p.done
+}
+
+// Poll replays the recording, then waits for stop.
+// It closes the updates channel before returning.
+func (p *ReplayPoller) Poll(b *Bot, updates chan<- any, stop chan struct{}) {
+	p.init()
+	defer close(updates)
+
+	if err := p.replay(b, updates, stop); err != nil {
+		b.handleError(fmt.Errorf("replay error: %w", err), nil, "poller")
+	}
+	close(p.done)
+	<-stop
+}
+
+func (p *ReplayPoller) replay(b *Bot, updates chan<- any, stop chan struct{}) error {
+	r := p.Reader
+	if r == nil {
+		f, err := os.Open(p.Path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	speed := p.Speed
+	if speed == 0 {
+		speed = 1
+	}
+
+	dec := json.NewDecoder(r)
+	var prev time.Time
+	for {
+		var rec RecordedUpdate
+		if err := dec.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if speed > 0 && !prev.IsZero() {
+			if d := time.Duration(float64(rec.Time.Sub(prev)) / speed); d > 0 {
+				timer := time.NewTimer(d)
+				select {
+				case <-timer.C:
+				case <-stop:
+					timer.Stop()
+					return nil
+				}
+			}
+		}
+		prev = rec.Time
+
+		upd, err := ParseUpdate(rec.Update)
+		if err != nil {
+			b.handleError(fmt.Errorf("parse update error: %w", err), nil, "poller")
+			continue
+		}
+		if upd == nil {
+			continue // Unknown update type, skip.
+		}
+		select {
+		case updates <- upd:
+		case <-stop:
+			return nil
+		}
+	}
+}
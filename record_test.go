@@ -0,0 +1,282 @@
+package maxigobot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// readRecording decodes every line of a recording.
+func readRecording(t *testing.T, data []byte) []RecordedUpdate {
+	t.Helper()
+	var recs []RecordedUpdate
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var rec RecordedUpdate
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestUpdateRecorder_Record(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewUpdateRecorder(&buf)
+
+	before := time.Now()
+	if err := r.Record(json.RawMessage(webhookUpdateJSON)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Record(json.RawMessage(`{"update_type":"future_type"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	recs := readRecording(t, buf.Bytes())
+	if len(recs) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(recs), buf.String())
+	}
+	if recs[0].Time.Before(before) {
+		t.Errorf("time = %v, want after %v", recs[0].Time, before)
+	}
+	upd, err := ParseUpdate(recs[0].Update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *upd.(*maxigo.MessageCreatedUpdate).Message.Body.Text; got != "hello" {
+		t.Errorf("text = %q, want %q", got, "hello")
+	}
+}
+
+func TestUpdateRecorder_Redact(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewUpdateRecorder(&buf)
+	r.Redact = RedactPersonalData
+
+	raw := `{"update_type":"message_created","timestamp":1000,"message":{` +
+		`"sender":{"user_id":9007199254740993,"first_name":"Ann"},` +
+		`"recipient":{"chat_id":2,"chat_type":"dialog"},"timestamp":1000,` +
+		`"body":{"mid":"m1","seq":1,"text":"my card is 1234","attachments":[` +
+		`{"type":"contact","payload":{"vcf_info":"BEGIN:VCARD\nTEL:+79990001122\nEND:VCARD"}}]}}}`
+	if err := r.Record(json.RawMessage(raw)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, secret := range []string{"1234", "+7999"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("recording contains %q:\n%s", secret, out)
+		}
+	}
+
+	upd, err := ParseUpdate(readRecording(t, buf.Bytes())[0].Update)
+	if err != nil {
+		t.Fatalf("redacted update does not parse: %v", err)
+	}
+	msg := upd.(*maxigo.MessageCreatedUpdate).Message
+	if *msg.Body.Text != "[redacted]" {
+		t.Errorf("text = %q", *msg.Body.Text)
+	}
+	if msg.Sender.UserID != 9007199254740993 || msg.Sender.FirstName != "Ann" {
+		t.Errorf("sender = %+v, want unchanged", msg.Sender)
+	}
+}
+
+func TestOpenUpdateRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "updates.jsonl")
+	for range 2 {
+		r, err := OpenUpdateRecorder(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Record(json.RawMessage(webhookUpdateJSON)); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(readRecording(t, data)); n != 2 {
+		t.Errorf("got %d lines, want 2 (appended)", n)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("perm = %o, want 600", perm)
+	}
+}
+
+func TestLongPoller_Recorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, `{"updates":[%s],"marker":1}`, webhookUpdateJSON)
+	}))
+	defer srv.Close()
+
+	b := newPollerTestBot(t, srv.URL)
+	var buf bytes.Buffer
+	poller := &LongPoller{Timeout: 1, Recorder: NewUpdateRecorder(&buf)}
+
+	updates := make(chan any)
+	stop := make(chan struct{})
+	go poller.Poll(b, updates, stop)
+	<-updates
+	close(stop)
+	for range updates {
+	}
+
+	recs := readRecording(t, buf.Bytes())
+	if len(recs) == 0 || !strings.Contains(string(recs[0].Update), `"text":"hello"`) {
+		t.Errorf("recording = %s", buf.String())
+	}
+}
+
+func TestWebhookPoller_Recorder(t *testing.T) {
+	var buf bytes.Buffer
+	p := &WebhookPoller{Secret: "s3cret", Recorder: NewUpdateRecorder(&buf)}
+
+	postWebhook(t, p, webhookUpdateJSON, "wrong")
+	if rec := postWebhook(t, p, webhookUpdateJSON, "s3cret"); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	if n := len(readRecording(t, buf.Bytes())); n != 1 {
+		t.Errorf("got %d recorded updates, want 1 (unauthenticated request skipped)", n)
+	}
+}
+
+func TestWebhookPoller_Recorder_skipsRejected(t *testing.T) {
+	var buf bytes.Buffer
+	p := &WebhookPoller{Secret: "s3cret", QueueSize: 1, Recorder: NewUpdateRecorder(&buf)}
+
+	postWebhook(t, p, webhookUpdateJSON, "s3cret")
+	if rec := postWebhook(t, p, webhookUpdateJSON, "s3cret"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("queue full: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	if n := len(readRecording(t, buf.Bytes())); n != 1 {
+		t.Errorf("got %d recorded updates, want 1 (rejected update skipped)", n)
+	}
+}
+
+// recording builds a recording of text updates sent at the given offsets.
+func recording(t *testing.T, offsets ...time.Duration) string {
+	t.Helper()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	for i, off := range offsets {
+		upd := textUpdate(100, 1, fmt.Sprintf("msg %d", i))
+		upd.UpdateType = maxigo.UpdateMessageCreated
+		raw, err := json.Marshal(upd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		line, err := json.Marshal(RecordedUpdate{Time: start.Add(off), Update: raw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(append(line, '\n'))
+	}
+	return buf.String()
+}
+
+// replayTexts runs p and returns the texts of the replayed updates.
+func replayTexts(t *testing.T, p *ReplayPoller) []string {
+	t.Helper()
+	updates := make(chan any, 10)
+	stop := make(chan struct{})
+	go p.Poll(&Bot{}, updates, stop)
+
+	select {
+	case <-p.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("replay did not finish")
+	}
+	close(stop)
+
+	var texts []string
+	for upd := range updates {
+		texts = append(texts, *upd.(*maxigo.MessageCreatedUpdate).Message.Body.Text)
+	}
+	return texts
+}
+
+func TestReplayPoller(t *testing.T) {
+	data := recording(t, 0, time.Second, 2*time.Second)
+
+	start := time.Now()
+	texts := replayTexts(t, &ReplayPoller{Reader: strings.NewReader(data), Speed: 20})
+	elapsed := time.Since(start)
+
+	if strings.Join(texts, ",") != "msg 0,msg 1,msg 2" {
+		t.Errorf("texts = %v", texts)
+	}
+	// 2s of recorded time at 20x speed take 100ms.
+	if elapsed < 90*time.Millisecond || elapsed > time.Second {
+		t.Errorf("replay took %v, want about 100ms", elapsed)
+	}
+}
+
+func TestReplayPoller_noDelay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "updates.jsonl")
+	if err := os.WriteFile(path, []byte(recording(t, 0, time.Hour)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	texts := replayTexts(t, &ReplayPoller{Path: path, Speed: -1})
+	if len(texts) != 2 {
+		t.Errorf("texts = %v", texts)
+	}
+}
+
+func TestReplayPoller_errors(t *testing.T) {
+	var errs []error
+	b := &Bot{OnError: func(err error, c Context) { errs = append(errs, err) }}
+
+	data := recording(t, 0) + `{"time":"2026-01-01T00:00:00Z","update":{"update_type":"message_created","message":1}}` + "\n" + `not json`
+	updates := make(chan any, 10)
+	stop := make(chan struct{})
+	p := &ReplayPoller{Reader: strings.NewReader(data), Speed: -1}
+	go p.Poll(b, updates, stop)
+	<-p.Done()
+	close(stop)
+	for range updates {
+	}
+
+	if len(errs) != 2 {
+		t.Fatalf("errors = %v, want parse and decode errors", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "parse update") || !strings.Contains(errs[1].Error(), "replay error") {
+		t.Errorf("errors = %v", errs)
+	}
+
+	errs = nil
+	p = &ReplayPoller{Path: filepath.Join(t.TempDir(), "missing.jsonl")}
+	updates = make(chan any)
+	stop = make(chan struct{})
+	go p.Poll(b, updates, stop)
+	<-p.Done()
+	close(stop)
+	for range updates {
+	}
+	if len(errs) != 1 {
+		t.Errorf("errors = %v, want open error", errs)
+	}
+}
@@ -2,6 +2,7 @@ package maxigobot
 
 import (
 	"encoding/json"
+	"slices"
 	"strings"
 
 	maxigo "github.com/maxigo-bot/maxigo-client"
@@ -58,27 +59,45 @@ var attachmentTypeToEndpoint = map[string]string{
 	"contact":  OnContact,
 	"image":    OnPhoto,
 	"location": OnLocation,
+	"video":    OnVideo,
+	"audio":    OnAudio,
+	"file":     OnFile,
+	"sticker":  OnSticker,
+	"share":    OnShare,
 }
 
-// firstAttachmentType extracts the "type" field from the first raw attachment
-// without fully parsing the attachment. Returns empty string if no attachments
-// or the first attachment cannot be unmarshaled.
-func firstAttachmentType(attachments []json.RawMessage) string {
-	if len(attachments) == 0 {
-		return ""
-	}
-	var header struct {
-		Type string `json:"type"`
-	}
-	if err := json.Unmarshal(attachments[0], &header); err != nil {
-		return ""
+// attachmentEndpoints returns the event endpoints of all recognized
+// attachments in order, without duplicates. Only the "type" field of each
+// raw attachment is decoded; attachments that cannot be unmarshaled or have
+// no event endpoint (e.g. inline keyboards) are skipped.
+func attachmentEndpoints(attachments []json.RawMessage) []string {
+	var eps []string
+	for _, raw := range attachments {
+		var header struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &header); err != nil {
+			continue
+		}
+		if ep, ok := attachmentTypeToEndpoint[header.Type]; ok && !slices.Contains(eps, ep) {
+			eps = append(eps, ep)
+		}
 	}
-	return header.Type
+	return eps
 }
 
 // isAttachmentEndpoint reports whether the endpoint is an attachment-based event.
 func isAttachmentEndpoint(ep string) bool {
-	return ep == OnContact || ep == OnPhoto || ep == OnLocation
+	switch ep {
+	case OnContact, OnPhoto, OnLocation, OnVideo, OnAudio, OnFile, OnSticker, OnShare, OnMedia:
+		return true
+	}
+	return false
+}
+
+// isMediaEndpoint reports whether the endpoint also matches OnMedia.
+func isMediaEndpoint(ep string) bool {
+	return ep == OnPhoto || ep == OnVideo || ep == OnAudio || ep == OnFile
 }
 
 // resolveEndpoint determines the endpoint key for a given update.
@@ -87,8 +106,8 @@ func resolveEndpoint(raw any) (endpoint string, command string, payload string)
 	switch u := raw.(type) {
 	case *maxigo.MessageCreatedUpdate:
 		// Check attachments first — attachment events take priority.
-		if ep, ok := attachmentTypeToEndpoint[firstAttachmentType(u.Message.Body.Attachments)]; ok {
-			return ep, "", ""
+		if eps := attachmentEndpoints(u.Message.Body.Attachments); len(eps) > 0 {
+			return eps[0], "", ""
 		}
 
 		if u.Message.Body.Text != nil {
@@ -146,77 +165,66 @@ func resolveEndpoint(raw any) (endpoint string, command string, payload string)
 	}
 }
 
-// findHandler looks up a handler in bot and group registries.
-// For message_created, it tries:
-//   - Attachment endpoints: exact match → OnText (if message has text) → OnMessage
+// routeCandidate is a handler that may handle an update, with its group
+// middleware and the routing results to record on the context.
+type routeCandidate struct {
+	entry   *handlerEntry
+	groupMW []MiddlewareFunc
+	// match holds the captures of a pattern handler.
+	match []string
+	// callbackArgs holds the payload arguments of a callback routed by prefix.
+	callbackArgs []string
+}
+
+// findHandlers returns the handlers for an update in the order they are
+// tried, searching groups first and then bot handlers for each endpoint.
+// For message_created, the fallback chain is:
+//   - Attachment endpoints: exact match → events of the other attachments →
+//     OnMedia (if any media attachment) → text patterns and OnText (if the
+//     message has a caption) → OnMessage
+//   - OnText: text patterns → OnText → OnMessage
 //   - Commands: exact match → OnText → OnMessage
-func (b *Bot) findHandler(endpoint string, update any) (*handlerEntry, []MiddlewareFunc) {
-	// Try exact match in groups first, then bot handlers.
-	if entry, groupMW := b.findInGroups(endpoint); entry != nil {
-		return entry, groupMW
-	}
-	if entry, ok := b.handlers[endpoint]; ok {
-		return entry, nil
-	}
+//
+// Callbacks try the full payload → the unique prefix → OnCallback("").
+func (b *Bot) findHandlers(endpoint string, update any) []routeCandidate {
+	cands := b.lookup(endpoint)
 
-	// Fallback chain for message_created.
-	if u, ok := update.(*maxigo.MessageCreatedUpdate); ok {
-		// Attachment endpoints fall back to OnText (if message has text) → OnMessage.
+	switch u := update.(type) {
+	case *maxigo.MessageCreatedUpdate:
+		if endpoint == OnText {
+			cands = append(b.textPatterns(&u.Message), cands...)
+		}
 		if isAttachmentEndpoint(endpoint) {
-			if u.Message.Body.Text != nil {
-				if entry, groupMW := b.findInGroups(OnText); entry != nil {
-					return entry, groupMW
-				}
-				if entry, ok := b.handlers[OnText]; ok {
-					return entry, nil
+			eps := attachmentEndpoints(u.Message.Body.Attachments)
+			for _, ep := range eps {
+				if ep != endpoint {
+					cands = append(cands, b.lookup(ep)...)
 				}
 			}
-			if entry, groupMW := b.findInGroups(OnMessage); entry != nil {
-				return entry, groupMW
+			if endpoint != OnMedia && slices.ContainsFunc(eps, isMediaEndpoint) {
+				cands = append(cands, b.lookup(OnMedia)...)
 			}
-			if entry, ok := b.handlers[OnMessage]; ok {
-				return entry, nil
+			if u.Message.Body.Text != nil {
+				cands = append(cands, b.textPatterns(&u.Message)...)
+				cands = append(cands, b.lookup(OnText)...)
 			}
-			return nil, nil
+			return append(cands, b.lookup(OnMessage)...)
 		}
 
 		// Commands fall back to OnText → OnMessage.
 		if endpoint != OnText && endpoint != OnMessage {
-			if entry, groupMW := b.findInGroups(OnText); entry != nil {
-				return entry, groupMW
-			}
-			if entry, ok := b.handlers[OnText]; ok {
-				return entry, nil
-			}
+			cands = append(cands, b.lookup(OnText)...)
 		}
 		if endpoint != OnMessage {
-			if entry, groupMW := b.findInGroups(OnMessage); entry != nil {
-				return entry, groupMW
-			}
-			if entry, ok := b.handlers[OnMessage]; ok {
-				return entry, nil
-			}
+			cands = append(cands, b.lookup(OnMessage)...)
 		}
-	}
 
-	// Fallback for callbacks: try catch-all callback handler.
-	if _, ok := update.(*maxigo.MessageCallbackUpdate); ok && endpoint != OnCallback("") {
-		if entry, groupMW := b.findInGroups(OnCallback("")); entry != nil {
-			return entry, groupMW
-		}
-		if entry, ok := b.handlers[OnCallback("")]; ok {
-			return entry, nil
+	case *maxigo.MessageCallbackUpdate:
+		cands = append(cands, b.callbackPrefixHandlers(u.Callback.Payload)...)
+		if endpoint != OnCallback("") {
+			cands = append(cands, b.lookup(OnCallback(""))...)
 		}
 	}
 
-	return nil, nil
-}
-
-func (b *Bot) findInGroups(endpoint string) (*handlerEntry, []MiddlewareFunc) {
-	for _, g := range b.groups {
-		if entry, ok := g.handlers[endpoint]; ok {
-			return entry, g.middleware
-		}
-	}
-	return nil, nil
+	return cands
 }
@@ -7,6 +7,15 @@ import (
 	maxigo "github.com/maxigo-bot/maxigo-client"
 )
 
+// firstHandler returns the first handler findHandlers finds for endpoint.
+func firstHandler(b *Bot, endpoint string, update any) (*handlerEntry, []MiddlewareFunc) {
+	cands := b.findHandlers(endpoint, update)
+	if len(cands) == 0 {
+		return nil, nil
+	}
+	return cands[0].entry, cands[0].groupMW
+}
+
 func TestParseCommand(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -192,7 +201,7 @@ func TestResolveEndpoint(t *testing.T) {
 			&maxigo.MessageCreatedUpdate{
 				Message: maxigo.Message{Body: maxigo.MessageBody{
 					Text:        ptrString("hello"),
-					Attachments: []json.RawMessage{json.RawMessage(`{"type":"sticker"}`)},
+					Attachments: []json.RawMessage{json.RawMessage(`{"type":"inline_keyboard"}`)},
 				}},
 			},
 			OnText, "", "",
@@ -201,11 +210,33 @@ func TestResolveEndpoint(t *testing.T) {
 			"unknown attachment no text",
 			&maxigo.MessageCreatedUpdate{
 				Message: maxigo.Message{Body: maxigo.MessageBody{
-					Attachments: []json.RawMessage{json.RawMessage(`{"type":"sticker"}`)},
+					Attachments: []json.RawMessage{json.RawMessage(`{"type":"inline_keyboard"}`)},
 				}},
 			},
 			OnMessage, "", "",
 		},
+		{
+			"sticker attachment",
+			&maxigo.MessageCreatedUpdate{
+				Message: maxigo.Message{Body: maxigo.MessageBody{
+					Attachments: []json.RawMessage{json.RawMessage(`{"type":"sticker"}`)},
+				}},
+			},
+			OnSticker, "", "",
+		},
+		{
+			"first recognized attachment wins",
+			&maxigo.MessageCreatedUpdate{
+				Message: maxigo.Message{Body: maxigo.MessageBody{
+					Attachments: []json.RawMessage{
+						json.RawMessage(`{"type":"inline_keyboard"}`),
+						json.RawMessage(`{"type":"video"}`),
+						json.RawMessage(`{"type":"image"}`),
+					},
+				}},
+			},
+			OnVideo, "", "",
+		},
 		{
 			"malformed attachment JSON falls through to text",
 			&maxigo.MessageCreatedUpdate{
@@ -391,7 +422,7 @@ func TestFindHandler_fallback(t *testing.T) {
 	b.handlers[OnText] = onTextHandler
 
 	// A command that has no handler should fall back to OnText.
-	entry, _ := b.findHandler("/unknown", &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, "/unknown", &maxigo.MessageCreatedUpdate{})
 	if entry != onTextHandler {
 		t.Error("should fall back to OnText handler")
 	}
@@ -404,7 +435,7 @@ func TestFindHandler_onMessageCatchAll(t *testing.T) {
 	b.handlers[OnMessage] = onMsgHandler
 
 	// OnText not registered — should fall back to OnMessage.
-	entry, _ := b.findHandler(OnText, &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, OnText, &maxigo.MessageCreatedUpdate{})
 	if entry != onMsgHandler {
 		t.Error("should fall back to OnMessage handler")
 	}
@@ -417,7 +448,7 @@ func TestFindHandler_callbackFallback(t *testing.T) {
 	b.handlers[OnCallback("")] = catchAll
 
 	// Specific callback not registered — should fall back to catch-all.
-	entry, _ := b.findHandler(OnCallback("unknown"), &maxigo.MessageCallbackUpdate{})
+	entry, _ := firstHandler(b, OnCallback("unknown"), &maxigo.MessageCallbackUpdate{})
 	if entry != catchAll {
 		t.Error("should fall back to catch-all callback handler")
 	}
@@ -432,7 +463,7 @@ func TestFindHandler_exactMatch(t *testing.T) {
 	b.handlers[OnText] = onTextHandler
 
 	// Exact command match should win over OnText.
-	entry, _ := b.findHandler("/start", &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, "/start", &maxigo.MessageCreatedUpdate{})
 	if entry != startHandler {
 		t.Error("should match exact /start handler, not OnText")
 	}
@@ -449,7 +480,7 @@ func TestFindHandler_groupPriority(t *testing.T) {
 	g.handlers["/start"] = groupHandler
 
 	// Group handler should be found first.
-	entry, _ := b.findHandler("/start", &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, "/start", &maxigo.MessageCreatedUpdate{})
 	if entry != groupHandler {
 		t.Error("group handler should take priority over bot handler")
 	}
@@ -464,7 +495,7 @@ func TestFindHandler_attachmentExactMatch(t *testing.T) {
 	b.handlers[OnText] = onTextHandler
 
 	// OnContact registered — should match exactly, not fall back to OnText.
-	entry, _ := b.findHandler(OnContact, &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, OnContact, &maxigo.MessageCreatedUpdate{})
 	if entry != contactHandler {
 		t.Error("should match exact OnContact handler")
 	}
@@ -477,7 +508,7 @@ func TestFindHandler_attachmentFallbackToOnText(t *testing.T) {
 	b.handlers[OnText] = onTextHandler
 
 	// OnContact not registered, message has text — should fall back to OnText.
-	entry, _ := b.findHandler(OnContact, &maxigo.MessageCreatedUpdate{
+	entry, _ := firstHandler(b, OnContact, &maxigo.MessageCreatedUpdate{
 		Message: maxigo.Message{Body: maxigo.MessageBody{Text: ptrString("John Doe")}},
 	})
 	if entry != onTextHandler {
@@ -492,7 +523,7 @@ func TestFindHandler_attachmentFallbackToOnMessage(t *testing.T) {
 	b.handlers[OnMessage] = onMsgHandler
 
 	// OnContact not registered, no OnText — should fall back to OnMessage.
-	entry, _ := b.findHandler(OnContact, &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, OnContact, &maxigo.MessageCreatedUpdate{})
 	if entry != onMsgHandler {
 		t.Error("should fall back to OnMessage when no attachment handler and no OnText")
 	}
@@ -507,7 +538,7 @@ func TestFindHandler_attachmentSkipsOnTextWhenNoText(t *testing.T) {
 	b.handlers[OnMessage] = onMsgHandler
 
 	// OnContact not registered, message has no text — should skip OnText, fall back to OnMessage.
-	entry, _ := b.findHandler(OnContact, &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, OnContact, &maxigo.MessageCreatedUpdate{})
 	if entry != onMsgHandler {
 		t.Error("should skip OnText and fall back to OnMessage when message has no text")
 	}
@@ -519,7 +550,7 @@ func TestFindHandler_attachmentNoHandler(t *testing.T) {
 	b := &Bot{handlers: make(map[string]*handlerEntry)}
 
 	// Attachment endpoint with no handlers at all — should return nil.
-	entry, _ := b.findHandler(OnContact, &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, OnContact, &maxigo.MessageCreatedUpdate{})
 	if entry != nil {
 		t.Error("should return nil when no handler matches attachment endpoint")
 	}
@@ -533,7 +564,7 @@ func TestFindHandler_attachmentGroupFallbackToOnText(t *testing.T) {
 	g.handlers[OnText] = groupTextHandler
 
 	// OnPhoto not registered, message has text, group has OnText — should fall back to group OnText.
-	entry, _ := b.findHandler(OnPhoto, &maxigo.MessageCreatedUpdate{
+	entry, _ := firstHandler(b, OnPhoto, &maxigo.MessageCreatedUpdate{
 		Message: maxigo.Message{Body: maxigo.MessageBody{Text: ptrString("caption")}},
 	})
 	if entry != groupTextHandler {
@@ -549,16 +580,84 @@ func TestFindHandler_attachmentGroupFallbackToOnMessage(t *testing.T) {
 	g.handlers[OnMessage] = groupMsgHandler
 
 	// OnLocation not registered, no text, group has OnMessage — should fall back to group OnMessage.
-	entry, _ := b.findHandler(OnLocation, &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, OnLocation, &maxigo.MessageCreatedUpdate{})
 	if entry != groupMsgHandler {
 		t.Error("should fall back to group OnMessage handler for attachment")
 	}
 }
 
+func TestFindHandler_attachmentOtherAttachments(t *testing.T) {
+	b := &Bot{handlers: make(map[string]*handlerEntry)}
+
+	photoHandler := &handlerEntry{handler: func(c Context) error { return nil }}
+	b.handlers[OnPhoto] = photoHandler
+
+	// First attachment is a sticker without handler; the photo handler matches.
+	entry, _ := firstHandler(b, OnSticker, &maxigo.MessageCreatedUpdate{
+		Message: maxigo.Message{Body: maxigo.MessageBody{Attachments: []json.RawMessage{
+			json.RawMessage(`{"type":"sticker"}`),
+			json.RawMessage(`{"type":"image"}`),
+		}}},
+	})
+	if entry != photoHandler {
+		t.Error("should match the handler of a later attachment")
+	}
+}
+
+func TestFindHandler_attachmentOnMedia(t *testing.T) {
+	b := &Bot{handlers: make(map[string]*handlerEntry)}
+
+	mediaHandler := &handlerEntry{handler: func(c Context) error { return nil }}
+	onTextHandler := &handlerEntry{handler: func(c Context) error { return nil }}
+	b.handlers[OnMedia] = mediaHandler
+	b.handlers[OnText] = onTextHandler
+
+	tests := []struct {
+		name     string
+		endpoint string
+		raw      string
+		want     *handlerEntry
+	}{
+		{"video", OnVideo, `{"type":"video"}`, mediaHandler},
+		{"audio", OnAudio, `{"type":"audio"}`, mediaHandler},
+		{"file", OnFile, `{"type":"file"}`, mediaHandler},
+		{"photo", OnPhoto, `{"type":"image"}`, mediaHandler},
+		{"sticker is not media", OnSticker, `{"type":"sticker"}`, onTextHandler},
+		{"share is not media", OnShare, `{"type":"share"}`, onTextHandler},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, _ := firstHandler(b, tt.endpoint, &maxigo.MessageCreatedUpdate{
+				Message: maxigo.Message{Body: maxigo.MessageBody{
+					Text:        ptrString("caption"),
+					Attachments: []json.RawMessage{json.RawMessage(tt.raw)},
+				}},
+			})
+			if entry != tt.want {
+				t.Errorf("%s: wrong handler", tt.name)
+			}
+		})
+	}
+}
+
+func TestAttachmentEndpoints(t *testing.T) {
+	got := attachmentEndpoints([]json.RawMessage{
+		json.RawMessage(`{"type":"image"}`),
+		json.RawMessage(`{invalid}`),
+		json.RawMessage(`{"type":"inline_keyboard"}`),
+		json.RawMessage(`{"type":"image"}`),
+		json.RawMessage(`{"type":"file"}`),
+	})
+	want := []string{OnPhoto, OnFile}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("attachmentEndpoints = %q, want %q", got, want)
+	}
+}
+
 func TestFindHandler_noMatch(t *testing.T) {
 	b := &Bot{handlers: make(map[string]*handlerEntry)}
 
-	entry, _ := b.findHandler("/nothing", &maxigo.MessageCreatedUpdate{})
+	entry, _ := firstHandler(b, "/nothing", &maxigo.MessageCreatedUpdate{})
 	if entry != nil {
 		t.Error("should return nil when no handler matches")
 	}
@@ -0,0 +1,521 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maxigo-bot/maxigo-bot/internal/fileutil"
+)
+
+// jobContextKey is the Context store key holding the running Job.
+const jobContextKey = "maxigobot.job"
+
+// ErrUnknownJob is reported to OnError when a scheduled job names a handler
+// that was not registered with HandleJob. The job is dropped.
+var ErrUnknownJob = errors.New("maxigobot: no handler registered for job")
+
+// Job is a scheduled task. Jobs are plain data, so they can be persisted by
+// a JobStore and survive restarts; the code to run is looked up by Name
+// among the handlers registered with HandleJob.
+type Job struct {
+	// ID identifies the job. Generated when scheduling if empty; scheduling
+	// a job with an existing ID replaces it.
+	ID string `json:"id"`
+	// Name is the job handler name (see Bot.HandleJob).
+	Name string `json:"name"`
+	// ChatID is the chat the job works with: Context.Chat and Send use it.
+	ChatID int64 `json:"chat_id,omitempty"`
+	// Payload is arbitrary data for the handler, returned by Context.Payload.
+	Payload string `json:"payload,omitempty"`
+	// RunAt is the time of the next run.
+	RunAt time.Time `json:"run_at"`
+	// Cron is the schedule of a recurring job ("" for one-shot jobs).
+	Cron string `json:"cron,omitempty"`
+}
+
+// JobFromContext returns the job being run by a job handler.
+func JobFromContext(c Context) (Job, bool) {
+	j, ok := c.Get(jobContextKey).(Job)
+	return j, ok
+}
+
+// JobStore persists scheduled jobs. Implementations must be safe for
+// concurrent use.
+type JobStore interface {
+	// SaveJob creates or replaces the job with job.ID.
+	SaveJob(ctx gocontext.Context, job Job) error
+	// DeleteJob removes a job. Deleting a missing job is not an error.
+	DeleteJob(ctx gocontext.Context, id string) error
+	// LoadJobs returns all stored jobs.
+	LoadJobs(ctx gocontext.Context) ([]Job, error)
+}
+
+// WithJobStore sets the storage for scheduled jobs.
+// Default: an in-memory store; jobs are lost on restart.
+// Use NewFileJobStore or a database-backed store to persist them.
+func WithJobStore(s JobStore) Option {
+	return func(b *Bot) {
+		b.jobStore = s
+	}
+}
+
+// HandleJob registers the handler run for scheduled jobs with the given name.
+// The handler receives a Context without an update: Chat and Payload return
+// the job's ChatID and Payload, Send sends to the job's chat, and
+// JobFromContext returns the job. Middleware is not applied.
+// Errors and panics are reported to OnError.
+func (b *Bot) HandleJob(name string, h HandlerFunc) {
+	s := b.scheduler()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[name] = h
+}
+
+// ScheduleAt schedules job to run once at t and returns its ID.
+func (b *Bot) ScheduleAt(t time.Time, job Job) (string, error) {
+	job.RunAt = t
+	job.Cron = ""
+	return b.scheduler().add(b.ctx, job)
+}
+
+// ScheduleAfter schedules job to run once after d and returns its ID.
+func (b *Bot) ScheduleAfter(d time.Duration, job Job) (string, error) {
+	return b.ScheduleAt(time.Now().Add(d), job)
+}
+
+// ScheduleCron schedules job to run repeatedly on a cron schedule and
+// returns its ID. spec is a standard 5-field expression
+// ("minute hour day-of-month month day-of-week") in local time, e.g.
+// "0 9 * * 1-5" for 9:00 on weekdays, or a descriptor such as @daily.
+func (b *Bot) ScheduleCron(spec string, job Job) (string, error) {
+	next, err := nextCronRun(spec, time.Now())
+	if err != nil {
+		return "", err
+	}
+	job.Cron = spec
+	job.RunAt = next
+	return b.scheduler().add(b.ctx, job)
+}
+
+// nextCronRun returns the first run of the cron spec after t.
+// Returns ErrInvalidCron if spec cannot be parsed or never runs.
+func nextCronRun(spec string, t time.Time) (time.Time, error) {
+	cron, err := parseCron(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := cron.next(t)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w %q: never runs", ErrInvalidCron, spec)
+	}
+	return next, nil
+}
+
+// Unschedule cancels a scheduled job. A job that is already running is not
+// interrupted. Unscheduling an unknown ID is not an error.
+func (b *Bot) Unschedule(id string) error {
+	return b.scheduler().remove(b.ctx, id)
+}
+
+// Jobs returns the pending jobs ordered by their next run time.
+func (b *Bot) Jobs() []Job {
+	s := b.scheduler()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].RunAt.Before(jobs[j].RunAt) })
+	return jobs
+}
+
+// scheduler returns the bot's scheduler, creating it on first use.
+func (b *Bot) scheduler() *scheduler {
+	b.schedOnce.Do(func() {
+		store := b.jobStore
+		if store == nil {
+			store = NewMemoryJobStore()
+		}
+		b.sched = &scheduler{
+			store:    store,
+			handlers: map[string]HandlerFunc{deleteJobName: runDeleteJob},
+			jobs:     make(map[string]Job),
+			wake:     make(chan struct{}, 1),
+		}
+	})
+	return b.sched
+}
+
+// scheduler keeps pending jobs in memory, mirrored to a JobStore.
+type scheduler struct {
+	store JobStore
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	jobs     map[string]Job
+	wake     chan struct{}
+}
+
+func (s *scheduler) add(ctx gocontext.Context, job Job) (string, error) {
+	if job.Name == "" {
+		return "", &BotError{Err: errors.New("maxigobot: job name is required")}
+	}
+	if job.ID == "" {
+		job.ID = newJobID()
+	}
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+	if err := s.store.SaveJob(ctx, job); err != nil {
+		return "", fmt.Errorf("maxigobot: save job: %w", err)
+	}
+
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	s.mu.Unlock()
+	s.notify()
+	return job.ID, nil
+}
+
+func (s *scheduler) remove(ctx gocontext.Context, id string) error {
+	s.mu.Lock()
+	delete(s.jobs, id)
+	s.mu.Unlock()
+	s.notify()
+
+	if ctx == nil {
+		ctx = gocontext.Background()
+	}
+	if err := s.store.DeleteJob(ctx, id); err != nil {
+		return fmt.Errorf("maxigobot: delete job: %w", err)
+	}
+	return nil
+}
+
+// notify wakes the run loop to recompute the next deadline.
+func (s *scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// load merges stored jobs into the pending set. Jobs scheduled in this
+// process take precedence over stored copies with the same ID. Recurring
+// jobs with an invalid cron spec are passed to drop instead.
+func (s *scheduler) load(ctx gocontext.Context, drop func(Job, error)) error {
+	jobs, err := s.store.LoadJobs(ctx)
+	if err != nil {
+		return fmt.Errorf("maxigobot: load jobs: %w", err)
+	}
+	var valid []Job
+	for _, j := range jobs {
+		if j.Cron != "" {
+			if _, err := nextCronRun(j.Cron, time.Now()); err != nil {
+				drop(j, err)
+				continue
+			}
+		}
+		valid = append(valid, j)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range valid {
+		if _, ok := s.jobs[j.ID]; !ok {
+			s.jobs[j.ID] = j
+		}
+	}
+	return nil
+}
+
+// due removes and returns the jobs due at now, along with the time of the
+// next pending job (zero if none).
+func (s *scheduler) due(now time.Time) (due []Job, next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, j := range s.jobs {
+		if !j.RunAt.After(now) {
+			due = append(due, j)
+			delete(s.jobs, id)
+			continue
+		}
+		if next.IsZero() || j.RunAt.Before(next) {
+			next = j.RunAt
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+	return due, next
+}
+
+// runScheduler runs due jobs until the bot stops. Jobs run in their own
+// goroutines tracked by b.wg, so Start returns only after they finish.
+// Jobs missed while the bot was down run once right after start.
+func (b *Bot) runScheduler() {
+	s := b.scheduler()
+	if err := s.load(b.ctx, b.dropJob); err != nil {
+		b.handleError(err, nil, "scheduler")
+	}
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		default:
+		}
+
+		due, next := s.due(time.Now())
+		for _, j := range due {
+			b.startJob(j)
+		}
+		if len(due) > 0 {
+			continue // Starting jobs may have rescheduled recurring ones.
+		}
+
+		wait := time.Hour
+		if !next.IsZero() {
+			wait = min(time.Until(next), wait)
+		}
+		timer.Reset(wait)
+		select {
+		case <-b.stop:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// startJob reschedules a recurring job and runs the job in a new goroutine.
+// A recurring job whose cron spec is invalid or never runs again is dropped.
+// A one-shot job is deleted from the store once it has run, unless it was
+// interrupted by shutdown: then it runs again after restart.
+func (b *Bot) startJob(j Job) {
+	s := b.scheduler()
+	endpoint := "job:" + j.Name
+	if j.Cron != "" {
+		next := j
+		var err error
+		if next.RunAt, err = nextCronRun(j.Cron, time.Now()); err != nil {
+			b.dropJob(j, err)
+			return
+		}
+		if _, err := s.add(b.ctx, next); err != nil {
+			b.handleError(err, nil, endpoint)
+		}
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		err := b.runJob(j)
+		if j.Cron != "" || (err != nil && b.ctx.Err() != nil && !errors.Is(err, ErrUnknownJob)) {
+			return
+		}
+		if err := s.store.DeleteJob(b.ctx, j.ID); err != nil {
+			b.handleError(fmt.Errorf("maxigobot: delete job: %w", err), nil, endpoint)
+		}
+	}()
+}
+
+// dropJob reports err for a job that cannot be run and deletes it from
+// the store.
+func (b *Bot) dropJob(j Job, err error) {
+	endpoint := "job:" + j.Name
+	b.handleError(&BotError{Endpoint: endpoint, Err: err}, nil, endpoint)
+	if err := b.scheduler().store.DeleteJob(b.ctx, j.ID); err != nil {
+		b.handleError(fmt.Errorf("maxigobot: delete job: %w", err), nil, endpoint)
+	}
+}
+
+// runJob runs the handler of j, reporting errors and panics to OnError.
+// It returns the reported error.
+func (b *Bot) runJob(j Job) (err error) {
+	endpoint := "job:" + j.Name
+	s := b.scheduler()
+	s.mu.Lock()
+	h := s.handlers[j.Name]
+	s.mu.Unlock()
+	if h == nil {
+		err = &BotError{Endpoint: endpoint, Err: ErrUnknownJob}
+		b.handleError(err, nil, endpoint)
+		return err
+	}
+
+	c := &nativeContext{
+		bot:     b,
+		meta:    updateMeta{chatID: j.ChatID},
+		payload: j.Payload,
+		ctx:     b.ctx,
+		store:   map[string]any{jobContextKey: j},
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic recovered: %v\n%s", r, debug.Stack())
+			b.handleError(err, c, endpoint)
+		}
+	}()
+
+	if err = h(c); err != nil {
+		b.handleError(err, c, endpoint)
+	}
+	return err
+}
+
+func newJobID() string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// MemoryJobStore is an in-memory JobStore. Jobs are lost on restart.
+type MemoryJobStore struct {
+	mu   sync.RWMutex
+	jobs map[string]Job
+}
+
+// NewMemoryJobStore creates an empty in-memory JobStore.
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]Job)}
+}
+
+// SaveJob implements JobStore.
+func (m *MemoryJobStore) SaveJob(_ gocontext.Context, job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[job.ID] = job
+	return nil
+}
+
+// DeleteJob implements JobStore.
+func (m *MemoryJobStore) DeleteJob(_ gocontext.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.jobs, id)
+	return nil
+}
+
+// LoadJobs implements JobStore.
+func (m *MemoryJobStore) LoadJobs(_ gocontext.Context) ([]Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	jobs := make([]Job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// FileJobStore is a JobStore that keeps jobs in memory and persists them to
+// a JSON file on every change, so scheduled jobs survive restarts. Each save
+// or delete writes all jobs again; for thousands of pending jobs implement
+// JobStore on top of a database instead.
+type FileJobStore struct {
+	path string
+
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// NewFileJobStore opens the job file at path, creating it on first write.
+func NewFileJobStore(path string) (*FileJobStore, error) {
+	s := &FileJobStore{path: path, jobs: make(map[string]Job)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("maxigobot: read job file: %w", err)
+	}
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("maxigobot: parse job file: %w", err)
+	}
+	for _, j := range jobs {
+		s.jobs[j.ID] = j
+	}
+	return s, nil
+}
+
+// SaveJob implements JobStore.
+func (s *FileJobStore) SaveJob(_ gocontext.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, had := s.jobs[job.ID]
+	s.jobs[job.ID] = job
+	if err := s.flush(); err != nil {
+		// Keep the job set in sync with what is on disk.
+		if had {
+			s.jobs[job.ID] = prev
+		} else {
+			delete(s.jobs, job.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteJob implements JobStore.
+func (s *FileJobStore) DeleteJob(_ gocontext.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, had := s.jobs[id]
+	if !had {
+		return nil
+	}
+	delete(s.jobs, id)
+	if err := s.flush(); err != nil {
+		s.jobs[id] = prev
+		return err
+	}
+	return nil
+}
+
+// LoadJobs implements JobStore.
+func (s *FileJobStore) LoadJobs(_ gocontext.Context) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+// flush atomically rewrites the job file. Caller must hold s.mu.
+func (s *FileJobStore) flush() error {
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	data, err := json.Marshal(jobs)
+	if err != nil {
+		return fmt.Errorf("maxigobot: encode jobs: %w", err)
+	}
+	if err := fileutil.WriteAtomic(s.path, data); err != nil {
+		return fmt.Errorf("maxigobot: write job file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,391 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startBot runs b with an empty poller and returns a function that stops
+// it and waits for Start to return.
+func startBot(t *testing.T, b *Bot) (stop func()) {
+	t.Helper()
+	b.poller = &mockPoller{}
+	done := make(chan struct{})
+	go func() {
+		b.Start()
+		close(done)
+	}()
+	return func() {
+		b.Stop()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Start() did not return after Stop()")
+		}
+	}
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestScheduler_ScheduleAfter(t *testing.T) {
+	var mu sync.Mutex
+	var sentTo string
+	b := testBotWithServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		sentTo = r.URL.Query().Get("chat_id")
+		mu.Unlock()
+		writeJSON(t, w, `{"message":{"recipient":{"chat_id":100,"chat_type":"dialog"},"timestamp":1,"body":{"mid":"m1","seq":1}}}`)
+	})
+
+	ran := make(chan struct{})
+	var got Job
+	var payload string
+	var chat int64
+	b.HandleJob("remind", func(c Context) error {
+		got, _ = JobFromContext(c)
+		payload, chat = c.Payload(), c.Chat()
+		defer close(ran)
+		return c.Send("Reminder: " + c.Payload())
+	})
+
+	id, err := b.ScheduleAfter(20*time.Millisecond, Job{Name: "remind", ChatID: 100, Payload: "call mom"})
+	if err != nil {
+		t.Fatalf("ScheduleAfter: %v", err)
+	}
+	if jobs := b.Jobs(); len(jobs) != 1 || jobs[0].ID != id {
+		t.Fatalf("Jobs() = %+v", jobs)
+	}
+
+	stop := startBot(t, b)
+	waitFor(t, ran)
+	stop()
+
+	if got.ID != id || payload != "call mom" || chat != 100 {
+		t.Errorf("job = %+v, payload %q, chat %d", got, payload, chat)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if sentTo != "100" {
+		t.Errorf("sent to chat %q, want 100", sentTo)
+	}
+	if len(b.Jobs()) != 0 {
+		t.Error("one-shot job should be removed after running")
+	}
+	stored, _ := b.scheduler().store.LoadJobs(gocontext.Background())
+	if len(stored) != 0 {
+		t.Errorf("store has %d jobs, want 0", len(stored))
+	}
+}
+
+func TestScheduler_Unschedule(t *testing.T) {
+	b, _ := New("token")
+	ran := make(chan struct{}, 1)
+	b.HandleJob("job", func(c Context) error {
+		ran <- struct{}{}
+		return nil
+	})
+
+	id, _ := b.ScheduleAfter(30*time.Millisecond, Job{Name: "job"})
+	stop := startBot(t, b)
+	if err := b.Unschedule(id); err != nil {
+		t.Fatalf("Unschedule: %v", err)
+	}
+	time.Sleep(80 * time.Millisecond)
+	stop()
+
+	select {
+	case <-ran:
+		t.Error("unscheduled job should not run")
+	default:
+	}
+	if err := b.Unschedule("unknown"); err != nil {
+		t.Errorf("Unschedule(unknown) = %v", err)
+	}
+}
+
+func TestScheduler_recurring(t *testing.T) {
+	b, _ := New("token")
+	ran := make(chan struct{}, 10)
+	b.HandleJob("digest", func(c Context) error {
+		ran <- struct{}{}
+		return nil
+	})
+
+	// A recurring job that was due while the bot was down runs once
+	// and is rescheduled to its next cron time.
+	_, err := b.scheduler().add(gocontext.Background(), Job{ID: "d", Name: "digest", Cron: "0 9 * * *", RunAt: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := startBot(t, b)
+	waitFor(t, ran)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+
+	if len(ran) != 0 {
+		t.Errorf("job ran %d extra times", len(ran))
+	}
+	jobs := b.Jobs()
+	if len(jobs) != 1 || !jobs[0].RunAt.After(time.Now()) || jobs[0].RunAt.Hour() != 9 {
+		t.Errorf("Jobs() = %+v, want rescheduled at 9:00", jobs)
+	}
+}
+
+func TestScheduler_ScheduleCron(t *testing.T) {
+	b := newTestBot()
+	if _, err := b.ScheduleCron("bad", Job{Name: "x"}); !errors.Is(err, ErrInvalidCron) {
+		t.Errorf("err = %v, want ErrInvalidCron", err)
+	}
+	if _, err := b.ScheduleCron("0 0 31 2 *", Job{Name: "x"}); !errors.Is(err, ErrInvalidCron) {
+		t.Errorf("never-matching spec: err = %v, want ErrInvalidCron", err)
+	}
+	if _, err := b.ScheduleCron("@daily", Job{}); err == nil {
+		t.Error("job without name should fail")
+	}
+
+	id, err := b.ScheduleCron("*/5 * * * *", Job{ID: "five", Name: "x"})
+	if err != nil || id != "five" {
+		t.Fatalf("ScheduleCron = %q, %v", id, err)
+	}
+	j := b.Jobs()[0]
+	if j.Cron != "*/5 * * * *" || j.RunAt.Minute()%5 != 0 || !j.RunAt.After(time.Now()) {
+		t.Errorf("job = %+v", j)
+	}
+}
+
+func TestScheduler_errors(t *testing.T) {
+	b, _ := New("token")
+	var mu sync.Mutex
+	var errs []error
+	done := make(chan struct{}, 3)
+	b.OnError = func(err error, c Context) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+		done <- struct{}{}
+	}
+	b.HandleJob("fail", func(c Context) error { return errors.New("boom") })
+	b.HandleJob("panic", func(c Context) error { panic("oops") })
+
+	_, _ = b.ScheduleAfter(0, Job{Name: "fail"})
+	_, _ = b.ScheduleAfter(0, Job{Name: "panic"})
+	_, _ = b.ScheduleAfter(0, Job{Name: "missing"})
+
+	stop := startBot(t, b)
+	for range 3 {
+		waitFor(t, done)
+	}
+	stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	var unknown int
+	for _, err := range errs {
+		if errors.Is(err, ErrUnknownJob) {
+			unknown++
+		}
+	}
+	if len(errs) != 3 || unknown != 1 {
+		t.Errorf("errors = %v", errs)
+	}
+}
+
+func TestScheduler_persistent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	store1, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatalf("NewFileJobStore: %v", err)
+	}
+	b1, _ := New("token", WithJobStore(store1))
+	if _, err := b1.ScheduleAfter(time.Millisecond, Job{ID: "r1", Name: "remind", ChatID: 7, Payload: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	// b1 is never started: the job survives in the file.
+
+	store2, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatalf("NewFileJobStore: %v", err)
+	}
+	b2, _ := New("token", WithJobStore(store2))
+	ran := make(chan Job, 1)
+	b2.HandleJob("remind", func(c Context) error {
+		j, _ := JobFromContext(c)
+		ran <- j
+		return nil
+	})
+
+	stop := startBot(t, b2)
+	var j Job
+	select {
+	case j = <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("persisted job did not run")
+	}
+	stop()
+
+	if j.ID != "r1" || j.ChatID != 7 || j.Payload != "hi" {
+		t.Errorf("job = %+v", j)
+	}
+	store3, _ := NewFileJobStore(path)
+	if jobs, _ := store3.LoadJobs(gocontext.Background()); len(jobs) != 0 {
+		t.Errorf("file has %d jobs after run, want 0", len(jobs))
+	}
+}
+
+func TestScheduler_stopDrainsJobs(t *testing.T) {
+	b, _ := New("token")
+	started := make(chan struct{})
+	finished := make(chan struct{})
+	b.HandleJob("slow", func(c Context) error {
+		close(started)
+		<-c.Ctx().Done()
+		time.Sleep(20 * time.Millisecond)
+		close(finished)
+		return c.Ctx().Err()
+	})
+	b.OnError = func(error, Context) {}
+	_, _ = b.ScheduleAfter(0, Job{ID: "s", Name: "slow"})
+
+	stop := startBot(t, b)
+	waitFor(t, started)
+	stop()
+
+	select {
+	case <-finished:
+	default:
+		t.Fatal("Start returned before the running job finished")
+	}
+	// The interrupted job stays in the store to run after restart.
+	if jobs, _ := b.scheduler().store.LoadJobs(gocontext.Background()); len(jobs) != 1 {
+		t.Errorf("store has %d jobs, want 1", len(jobs))
+	}
+}
+
+func TestScheduler_finishedDuringStopIsDeleted(t *testing.T) {
+	b, _ := New("token")
+	started := make(chan struct{})
+	b.HandleJob("remind", func(c Context) error {
+		close(started)
+		<-c.Ctx().Done()
+		return nil // The reminder was sent before the bot stopped.
+	})
+	_, _ = b.ScheduleAfter(0, Job{ID: "r", Name: "remind"})
+
+	stop := startBot(t, b)
+	waitFor(t, started)
+	stop()
+
+	if jobs, _ := b.scheduler().store.LoadJobs(gocontext.Background()); len(jobs) != 0 {
+		t.Errorf("store has %d jobs, want 0", len(jobs))
+	}
+}
+
+func TestScheduler_invalidStoredCron(t *testing.T) {
+	store := NewMemoryJobStore()
+	past := time.Now().Add(-time.Hour)
+	_ = store.SaveJob(gocontext.Background(), Job{ID: "bad", Name: "tick", Cron: "bad", RunAt: past})
+	_ = store.SaveJob(gocontext.Background(), Job{ID: "never", Name: "tick", Cron: "0 0 31 2 *", RunAt: past})
+
+	b, _ := New("token", WithJobStore(store))
+	var mu sync.Mutex
+	var errs []error
+	b.OnError = func(err error, c Context) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+	var runs int
+	b.HandleJob("tick", func(c Context) error {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		return nil
+	})
+
+	stop := startBot(t, b)
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if runs != 0 {
+		t.Errorf("job ran %d times, want 0", runs)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], ErrInvalidCron) || !errors.Is(errs[1], ErrInvalidCron) {
+		t.Errorf("errors = %v, want 2 ErrInvalidCron", errs)
+	}
+	if jobs, _ := store.LoadJobs(gocontext.Background()); len(jobs) != 0 {
+		t.Errorf("store has %d jobs, want 0", len(jobs))
+	}
+}
+
+func TestScheduler_startJob_invalidCron(t *testing.T) {
+	b, _ := New("token")
+	var gotErr error
+	b.OnError = func(err error, c Context) { gotErr = err }
+	b.HandleJob("tick", func(c Context) error {
+		t.Error("job with an invalid cron spec ran")
+		return nil
+	})
+	_ = b.scheduler().store.SaveJob(gocontext.Background(), Job{ID: "bad", Name: "tick", Cron: "bad"})
+
+	b.startJob(Job{ID: "bad", Name: "tick", Cron: "bad"})
+	b.wg.Wait()
+
+	if !errors.Is(gotErr, ErrInvalidCron) {
+		t.Errorf("OnError got %v, want ErrInvalidCron", gotErr)
+	}
+	if len(b.Jobs()) != 0 {
+		t.Errorf("job was rescheduled: %+v", b.Jobs())
+	}
+	if jobs, _ := b.scheduler().store.LoadJobs(gocontext.Background()); len(jobs) != 0 {
+		t.Errorf("store has %d jobs, want 0", len(jobs))
+	}
+}
+
+func TestFileJobStore(t *testing.T) {
+	ctx := gocontext.Background()
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	s, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatalf("NewFileJobStore: %v", err)
+	}
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	_ = s.SaveJob(ctx, Job{ID: "a", Name: "n", ChatID: 1, RunAt: at, Cron: "0 9 * * *"})
+	_ = s.SaveJob(ctx, Job{ID: "b", Name: "n"})
+	_ = s.DeleteJob(ctx, "b")
+	if err := s.DeleteJob(ctx, "missing"); err != nil {
+		t.Errorf("DeleteJob(missing) = %v", err)
+	}
+
+	reopened, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	jobs, _ := reopened.LoadJobs(ctx)
+	want := Job{ID: "a", Name: "n", ChatID: 1, RunAt: at, Cron: "0 9 * * *"}
+	if len(jobs) != 1 || jobs[0].ID != want.ID || !jobs[0].RunAt.Equal(at) || jobs[0].Cron != want.Cron {
+		t.Errorf("jobs = %+v, want [%+v]", jobs, want)
+	}
+
+	if _, err := NewFileJobStore(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("missing file should not be an error: %v", err)
+	}
+}
+
+var _ JobStore = (*MemoryJobStore)(nil)
+var _ JobStore = (*FileJobStore)(nil)
@@ -0,0 +1,75 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"errors"
+)
+
+// ErrNoStateStorage is returned by Context.SetState when the bot was created
+// without WithStateStorage.
+var ErrNoStateStorage = errors.New("maxigobot: no state storage configured")
+
+// StateKey identifies a conversation: a user within a chat.
+type StateKey struct {
+	ChatID int64
+	UserID int64
+}
+
+// StateStorage persists conversation state between updates.
+// Implementations must be safe for concurrent use.
+// See the fsm package for in-memory and file-backed implementations.
+type StateStorage interface {
+	// GetState returns the current state for key, or "" if none is set.
+	GetState(ctx gocontext.Context, key StateKey) (string, error)
+	// SetState stores the state for key. An empty state clears it.
+	SetState(ctx gocontext.Context, key StateKey, state string) error
+}
+
+// WithStateStorage enables conversation state (Context.State / Context.SetState)
+// backed by the given storage.
+func WithStateStorage(s StateStorage) Option {
+	return func(b *Bot) {
+		b.states = s
+	}
+}
+
+// stateKey returns the conversation key of the current update.
+func (c *nativeContext) stateKey() StateKey {
+	key := StateKey{ChatID: c.meta.chatID}
+	if c.meta.sender != nil {
+		key.UserID = c.meta.sender.UserID
+	}
+	return key
+}
+
+func (c *nativeContext) State() string {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	if c.stateLoaded || c.bot.states == nil {
+		return c.state
+	}
+	state, err := c.bot.states.GetState(c.Ctx(), c.stateKey())
+	if err != nil {
+		c.bot.handleError(&BotError{Err: err}, c, "state")
+		return ""
+	}
+	c.state = state
+	c.stateLoaded = true
+	return state
+}
+
+func (c *nativeContext) SetState(state string) error {
+	if c.bot.states == nil {
+		return &BotError{Err: ErrNoStateStorage}
+	}
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	if err := c.bot.states.SetState(c.Ctx(), c.stateKey(), state); err != nil {
+		return err
+	}
+	c.state = state
+	c.stateLoaded = true
+	return nil
+}
@@ -0,0 +1,162 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"errors"
+	"sync"
+	"testing"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// mapStateStorage is a trivial StateStorage for tests.
+type mapStateStorage struct {
+	mu     sync.Mutex
+	states map[StateKey]string
+	gets   int
+}
+
+func (s *mapStateStorage) GetState(_ gocontext.Context, key StateKey) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gets++
+	return s.states[key], nil
+}
+
+func (s *mapStateStorage) SetState(_ gocontext.Context, key StateKey, state string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.states == nil {
+		s.states = make(map[StateKey]string)
+	}
+	s.states[key] = state
+	return nil
+}
+
+// inState is a test filter equivalent to fsm.InState.
+func inState(state string) Filter {
+	return func(c Context) bool {
+		return c.State() == state
+	}
+}
+
+func textUpdate(chatID, userID int64, text string) *maxigo.MessageCreatedUpdate {
+	return &maxigo.MessageCreatedUpdate{Message: maxigo.Message{
+		Sender:    &maxigo.User{UserID: userID},
+		Recipient: maxigo.Recipient{ChatID: &chatID},
+		Body:      maxigo.MessageBody{Text: &text},
+	}}
+}
+
+func TestNativeContext_State(t *testing.T) {
+	storage := &mapStateStorage{}
+	b, _ := New("token", WithStateStorage(storage))
+	ctx := newTestContext(b, textUpdate(10, 20, "hi"))
+
+	if got := ctx.State(); got != "" {
+		t.Errorf("initial State() = %q, want empty", got)
+	}
+	if err := ctx.SetState("await_email"); err != nil {
+		t.Fatalf("SetState: %v", err)
+	}
+	if got := ctx.State(); got != "await_email" {
+		t.Errorf("State() = %q, want await_email", got)
+	}
+	if got := storage.states[StateKey{ChatID: 10, UserID: 20}]; got != "await_email" {
+		t.Errorf("stored state = %q, want await_email", got)
+	}
+	if storage.gets != 1 {
+		t.Errorf("storage reads = %d, want 1 (state is cached per update)", storage.gets)
+	}
+}
+
+func TestNativeContext_SetState_noStorage(t *testing.T) {
+	ctx := newTestContext(newTestBot(), textUpdate(1, 1, "hi"))
+
+	err := ctx.SetState("x")
+	if !errors.Is(err, ErrNoStateStorage) {
+		t.Errorf("error = %v, want ErrNoStateStorage", err)
+	}
+	if got := ctx.State(); got != "" {
+		t.Errorf("State() = %q, want empty", got)
+	}
+}
+
+func TestBot_ProcessUpdate_stateRouting(t *testing.T) {
+	storage := &mapStateStorage{}
+	b, _ := New("token", WithStateStorage(storage))
+
+	var got []string
+	b.Handle(OnText, func(c Context) error {
+		got = append(got, "fallback")
+		return nil
+	})
+	b.HandleFiltered(OnText, inState("await_email"), func(c Context) error {
+		got = append(got, "email:"+c.Text())
+		return c.SetState("await_name")
+	})
+	b.HandleFiltered(OnText, inState("await_name"), func(c Context) error {
+		got = append(got, "name:"+c.Text())
+		return c.SetState("")
+	})
+
+	_ = storage.SetState(gocontext.Background(), StateKey{ChatID: 1, UserID: 2}, "await_email")
+
+	b.processUpdate(textUpdate(1, 2, "a@b.c"))
+	b.processUpdate(textUpdate(1, 2, "Ann"))
+	b.processUpdate(textUpdate(1, 2, "hello"))
+	b.processUpdate(textUpdate(1, 3, "other user"))
+
+	want := []string{"email:a@b.c", "name:Ann", "fallback", "fallback"}
+	if len(got) != len(want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("calls[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBot_ProcessUpdate_allFilteredSkip(t *testing.T) {
+	b, _ := New("token", WithStateStorage(&mapStateStorage{}))
+
+	var gotErr error
+	b.OnError = func(err error, c Context) { gotErr = err }
+
+	called := false
+	b.HandleFiltered(OnText, inState("await_email"), func(c Context) error {
+		called = true
+		return nil
+	})
+
+	b.processUpdate(textUpdate(1, 2, "hi"))
+
+	if called {
+		t.Error("filtered handler should not be called")
+	}
+	if gotErr != nil {
+		t.Errorf("ErrSkip should not be reported, got %v", gotErr)
+	}
+}
+
+func TestGroup_HandleFiltered(t *testing.T) {
+	storage := &mapStateStorage{}
+	b, _ := New("token", WithStateStorage(storage))
+	g := b.Group()
+
+	var got string
+	g.Handle(OnText, func(c Context) error { got = "plain"; return nil })
+	g.HandleFiltered(OnText, inState("await_email"), func(c Context) error { got = "filtered"; return nil })
+
+	b.processUpdate(textUpdate(1, 2, "hi"))
+	if got != "plain" {
+		t.Errorf("without state: got %q, want %q", got, "plain")
+	}
+
+	_ = storage.SetState(gocontext.Background(), StateKey{ChatID: 1, UserID: 2}, "await_email")
+	b.processUpdate(textUpdate(1, 2, "a@b.c"))
+	if got != "filtered" {
+		t.Errorf("in state: got %q, want %q", got, "filtered")
+	}
+}
@@ -0,0 +1,73 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"time"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+// ErrHandlerTimeout is reported to OnError, wrapped in a BotError, when an
+// update is not handled within its deadline (see WithHandlerTimeout and
+// middleware.Timeout).
+var ErrHandlerTimeout = errors.New("maxigobot: handler timed out")
+
+// updateInfoKey is the context key of UpdateInfo.
+type updateInfoKey struct{}
+
+// UpdateInfo describes the update being handled. It is carried by the
+// request-scoped context returned by Context.Ctx, e.g. for logging from
+// code that only receives a context.Context.
+type UpdateInfo struct {
+	// UpdateType is the type of the update.
+	UpdateType maxigo.UpdateType
+	// ChatID is the chat of the update (0 if unavailable).
+	ChatID int64
+	// UserID is the sender of the update (0 if unavailable).
+	UserID int64
+	// Endpoint is the routing endpoint, e.g. "/start" or OnText.
+	Endpoint string
+}
+
+// UpdateInfoFromContext returns the UpdateInfo carried by ctx.
+func UpdateInfoFromContext(ctx gocontext.Context) (UpdateInfo, bool) {
+	info, ok := ctx.Value(updateInfoKey{}).(UpdateInfo)
+	return info, ok
+}
+
+// withUpdateInfo returns a copy of ctx carrying info.
+func withUpdateInfo(ctx gocontext.Context, info UpdateInfo) gocontext.Context {
+	return gocontext.WithValue(ctx, updateInfoKey{}, info)
+}
+
+// WithHandlerTimeout limits the time to handle each update, including
+// middleware. The request-scoped context (Context.Ctx) is canceled when
+// the timeout expires, which aborts API calls made with it; handlers doing
+// other long work should watch it too. A handler that overruns its
+// timeout is reported to OnError with ErrHandlerTimeout.
+//
+// Use middleware.Timeout to set timeouts for individual handlers or groups.
+// Zero (the default) means no timeout.
+func WithHandlerTimeout(d time.Duration) Option {
+	return func(b *Bot) {
+		b.handlerTimeout = d
+	}
+}
+
+// TimeoutError returns the error to report for a handler that ran with ctx
+// and returned err: a BotError wrapping ErrHandlerTimeout (and err, if any)
+// when the deadline of ctx expired but its parent is still active, and err
+// otherwise. It is used by WithHandlerTimeout and middleware.Timeout.
+func TimeoutError(ctx, parent gocontext.Context, err error) error {
+	if !errors.Is(ctx.Err(), gocontext.DeadlineExceeded) || parent.Err() != nil || errors.Is(err, ErrHandlerTimeout) {
+		return err
+	}
+	info, _ := UpdateInfoFromContext(ctx)
+	timeoutErr := ErrHandlerTimeout
+	if err != nil {
+		timeoutErr = fmt.Errorf("%w: %w", ErrHandlerTimeout, err)
+	}
+	return &BotError{Endpoint: info.Endpoint, Err: timeoutErr}
+}
@@ -0,0 +1,103 @@
+package maxigobot
+
+import (
+	gocontext "context"
+	"errors"
+	"testing"
+	"time"
+
+	maxigo "github.com/maxigo-bot/maxigo-client"
+)
+
+func TestProcessUpdate_updateInfo(t *testing.T) {
+	b, _ := New("token")
+	upd := textUpdate(100, 7, "/start")
+	upd.UpdateType = maxigo.UpdateMessageCreated
+
+	var info UpdateInfo
+	var ok, hasDeadline bool
+	b.Handle("/start", func(c Context) error {
+		info, ok = UpdateInfoFromContext(c.Ctx())
+		_, hasDeadline = c.Ctx().Deadline()
+		return nil
+	})
+	b.processUpdate(upd)
+
+	want := UpdateInfo{UpdateType: maxigo.UpdateMessageCreated, ChatID: 100, UserID: 7, Endpoint: "/start"}
+	if !ok || info != want {
+		t.Errorf("UpdateInfo = %+v, %v; want %+v", info, ok, want)
+	}
+	if hasDeadline {
+		t.Error("context has a deadline without WithHandlerTimeout")
+	}
+}
+
+func TestWithHandlerTimeout(t *testing.T) {
+	b, _ := New("token", WithHandlerTimeout(10*time.Millisecond))
+	var gotErr error
+	b.OnError = func(err error, c Context) { gotErr = err }
+	b.Handle(OnText, func(c Context) error {
+		<-c.Ctx().Done()
+		return nil
+	})
+
+	start := time.Now()
+	b.processUpdate(textUpdate(1, 1, "hi"))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("handler ran for %v", elapsed)
+	}
+
+	if !errors.Is(gotErr, ErrHandlerTimeout) {
+		t.Fatalf("OnError got %v, want ErrHandlerTimeout", gotErr)
+	}
+	var botErr *BotError
+	if !errors.As(gotErr, &botErr) || botErr.Endpoint != OnText {
+		t.Errorf("error = %#v, want a BotError for %q", gotErr, OnText)
+	}
+}
+
+func TestWithHandlerTimeout_inTime(t *testing.T) {
+	b, _ := New("token", WithHandlerTimeout(time.Minute))
+	var gotErr error
+	b.OnError = func(err error, c Context) { gotErr = err }
+	var hasDeadline bool
+	b.Handle(OnText, func(c Context) error {
+		_, hasDeadline = c.Ctx().Deadline()
+		return nil
+	})
+
+	b.processUpdate(textUpdate(1, 1, "hi"))
+	if gotErr != nil {
+		t.Errorf("unexpected error: %v", gotErr)
+	}
+	if !hasDeadline {
+		t.Error("context has no deadline")
+	}
+}
+
+func TestTimeoutError(t *testing.T) {
+	errHandler := errors.New("handler error")
+	background := gocontext.Background()
+	expired, cancel := gocontext.WithTimeout(withUpdateInfo(background, UpdateInfo{Endpoint: "/x"}), -1)
+	defer cancel()
+
+	if err := TimeoutError(background, background, errHandler); err != errHandler {
+		t.Errorf("no deadline: error = %v, want the handler error", err)
+	}
+
+	err := TimeoutError(expired, background, errHandler)
+	var botErr *BotError
+	if !errors.As(err, &botErr) || botErr.Endpoint != "/x" ||
+		!errors.Is(err, ErrHandlerTimeout) || !errors.Is(err, errHandler) {
+		t.Errorf("expired: error = %v", err)
+	}
+	if again := TimeoutError(expired, background, err); again != err {
+		t.Errorf("timeout error wrapped twice: %v", again)
+	}
+
+	canceled, cancelParent := gocontext.WithCancel(background)
+	cancelParent()
+	if err := TimeoutError(expired, canceled, nil); err != nil {
+		t.Errorf("canceled parent: error = %v, want nil", err)
+	}
+}
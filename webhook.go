@@ -2,6 +2,7 @@ package maxigobot
 
 import (
 	"crypto/subtle"
+	"fmt"
 	"io"
 	"net/http"
 	"sync"
@@ -36,6 +37,10 @@ const (
 // queue is full or the bot is stopped, the handler replies 503 so that the
 // Max Bot API redelivers the update later. Unknown update types are
 // acknowledged with 200 and skipped, matching LongPoller behavior.
+//
+// Queued updates have already been acknowledged, so when the bot stops they
+// are still passed to it (see Bot.Shutdown), unless the bot is stopped with
+// Bot.Stop or its Shutdown deadline expires.
 type WebhookPoller struct {
 	// Secret is the expected value of the X-Max-Bot-Api-Secret header, as
 	// passed to Client.Subscribe. The comparison is constant-time. An empty
@@ -47,12 +52,16 @@ type WebhookPoller struct {
 	QueueSize int
 	// MaxBodySize limits the accepted request body size in bytes (default 1 MB).
 	MaxBodySize int64
+	// Recorder, if set, records the raw JSON of every queued update.
+	// Recording errors are reported to Bot.OnError once Poll has started.
+	Recorder *UpdateRecorder
 
 	initOnce sync.Once
 	queue    chan any
 
 	mu   sync.Mutex
 	stop <-chan struct{} // set by Poll; nil until the poller starts
+	bot  *Bot            // set by Poll; used to report recording errors
 }
 
 // init lazily creates the internal queue so that ServeHTTP can accept
@@ -67,14 +76,18 @@ func (p *WebhookPoller) init() {
 	})
 }
 
-// Poll forwards queued webhook updates to the bot until stop is closed.
+// Poll forwards queued webhook updates to the bot until stop is closed,
+// then drains the queue (see WebhookPoller).
 // It closes the updates channel before returning, as required by [Poller].
-// The Bot argument is unused and may be nil.
-func (p *WebhookPoller) Poll(_ *Bot, updates chan<- any, stop chan struct{}) {
+// The Bot argument is used to report Recorder errors and to abort draining
+// when the bot's context is canceled. It may be nil, in which case queued
+// updates are dropped on stop.
+func (p *WebhookPoller) Poll(b *Bot, updates chan<- any, stop chan struct{}) {
 	p.init()
 
 	p.mu.Lock()
 	p.stop = stop
+	p.bot = b
 	p.mu.Unlock()
 
 	defer close(updates)
@@ -84,9 +97,40 @@ func (p *WebhookPoller) Poll(_ *Bot, updates chan<- any, stop chan struct{}) {
 			select {
 			case updates <- upd:
 			case <-stop:
+				p.drain(b, upd, updates)
 				return
 			}
 		case <-stop:
+			p.drain(b, nil, updates)
+			return
+		}
+	}
+}
+
+// drain forwards pending and the queued updates to the bot after stop,
+// until the queue is empty or the bot's context is canceled.
+func (p *WebhookPoller) drain(b *Bot, pending any, updates chan<- any) {
+	if b == nil || b.ctx == nil {
+		return
+	}
+	// ServeHTTP checks stop and enqueues under mu, so once mu is acquired
+	// no more updates can be queued.
+	p.mu.Lock()
+	p.mu.Unlock()
+
+	abort := b.ctx.Done()
+	for {
+		if pending == nil {
+			select {
+			case pending = <-p.queue:
+			default:
+				return
+			}
+		}
+		select {
+		case updates <- pending:
+			pending = nil
+		case <-abort:
 			return
 		}
 	}
@@ -128,23 +172,50 @@ func (p *WebhookPoller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check stop and enqueue atomically, so that Poll drains every update
+	// acknowledged before the bot stopped.
 	p.mu.Lock()
-	stop := p.stop
-	p.mu.Unlock()
-	if stop != nil {
+	stopped, queued := false, false
+	if p.stop != nil {
 		select {
-		case <-stop:
-			http.Error(w, "bot is stopped", http.StatusServiceUnavailable)
-			return
+		case <-p.stop:
+			stopped = true
 		default:
 		}
 	}
+	if !stopped {
+		select {
+		case p.queue <- upd:
+			queued = true
+		default:
+		}
+	}
+	p.mu.Unlock()
 
-	select {
-	case p.queue <- upd:
-		w.WriteHeader(http.StatusOK)
-	default:
+	switch {
+	case stopped:
+		http.Error(w, "bot is stopped", http.StatusServiceUnavailable)
+	case !queued:
 		// Queue full: ask the Max Bot API to redeliver later.
 		http.Error(w, "update queue is full", http.StatusServiceUnavailable)
+	default:
+		p.record(body)
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// record records an accepted update. Updates rejected with 503 are not
+// recorded, since the Max Bot API redelivers them.
+func (p *WebhookPoller) record(body []byte) {
+	if p.Recorder == nil {
+		return
+	}
+	if err := p.Recorder.Record(body); err != nil {
+		p.mu.Lock()
+		b := p.bot
+		p.mu.Unlock()
+		if b != nil {
+			b.handleError(fmt.Errorf("record update error: %w", err), nil, "poller")
+		}
 	}
 }
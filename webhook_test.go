@@ -1,9 +1,12 @@
 package maxigobot
 
 import (
+	gocontext "context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -234,3 +237,50 @@ func TestWithPoller(t *testing.T) {
 		t.Errorf("poller = %v, want the WebhookPoller instance", b.poller)
 	}
 }
+
+func TestWebhookPoller_ShutdownDrainsQueue(t *testing.T) {
+	p := &WebhookPoller{Secret: "s3cret"}
+	b, _ := New("token", WithPoller(p), WithWorkers(1))
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	var handled atomic.Int32
+	b.Handle(OnText, func(c Context) error {
+		once.Do(func() { close(started) })
+		<-release
+		handled.Add(1)
+		return nil
+	})
+	done := runStart(b)
+
+	// The single worker blocks on the first update; the others wait in the
+	// poller and dispatcher queues.
+	postWebhook(t, p, webhookUpdateJSON, "s3cret")
+	waitFor(t, started)
+	for range 3 {
+		if rec := postWebhook(t, p, webhookUpdateJSON, "s3cret"); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d", rec.Code)
+		}
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := b.Shutdown(gocontext.Background())
+		result <- err
+	}()
+
+	// Intake is stopped right away; new deliveries are rejected.
+	waitFor(t, b.stop)
+	if rec := postWebhook(t, p, webhookUpdateJSON, "s3cret"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status after Shutdown = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	close(release)
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, done)
+	if n := handled.Load(); n != 4 {
+		t.Errorf("handled %d updates, want 4 (queued updates drained)", n)
+	}
+}